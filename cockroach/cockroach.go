@@ -0,0 +1,287 @@
+// Package cockroach provides CockroachDB test database support for testdb.
+//
+// CockroachDB speaks the PostgreSQL wire protocol, so test databases are
+// reached with pgx and the postgres package's initializers, and the migration
+// runners work unchanged (the test DSN uses the postgres:// scheme). The
+// provider differs from postgres.PostgresProvider where CockroachDB does:
+//   - Connections are closed with CANCEL SESSIONS, found via SHOW CLUSTER
+//     SESSIONS, instead of pg_terminate_backend.
+//   - Databases are dropped with DROP DATABASE ... CASCADE, which doesn't wait
+//     for other sessions to disconnect.
+//   - Schema isolation, WithDBOwner, WithRoles, WithTemplate and WithAppName
+//     are not supported: Initialize fails with ErrUnsupportedOption (wrapping
+//     testdb.ErrIsolationNotSupported for schema isolation).
+//
+// # Basic Usage
+//
+//	func TestUsers(t *testing.T) {
+//	    pool := cockroach.Setup(t,
+//	        testdb.WithAdminDSN("postgresql://root@localhost:26257/defaultdb?sslmode=disable"),
+//	        testdb.WithMigrations("./migrations"),
+//	        testdb.WithMigrationTool(testdb.MigrationToolGoose))
+//
+//	    _, err := pool.Exec(ctx, "INSERT INTO users (email) VALUES ($1)", "test@example.com")
+//	    require.NoError(t, err)
+//	}
+//
+// The admin DSN is resolved like for PostgreSQL (WithAdminDSN,
+// TEST_DATABASE_URL, DATABASE_URL), defaulting to an insecure local
+// single-node cluster.
+package cockroach
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrUnsupportedOption is returned by Initialize when the config sets an
+// option the CockroachDB provider doesn't support.
+var ErrUnsupportedOption = errors.New("option not supported by the CockroachDB provider")
+
+// defaultCockroachDSN is the admin DSN of an insecure local single-node cluster
+// (cockroach start-single-node --insecure).
+const defaultCockroachDSN = "postgresql://root@localhost:26257/defaultdb?sslmode=disable"
+
+// CockroachProvider implements testdb.Provider for CockroachDB.
+type CockroachProvider struct {
+	conn     *pgx.Conn // Admin connection
+	adminDSN string    // Resolved admin DSN, used for migrations and BuildDSN
+}
+
+// Initialize connects to the admin database of the CockroachDB cluster. It
+// fails with ErrUnsupportedOption if cfg sets an option the provider doesn't
+// support, rather than ignoring it.
+func (p *CockroachProvider) Initialize(ctx context.Context, cfg testdb.Config) error {
+	if err := checkSupported(cfg); err != nil {
+		return err
+	}
+
+	p.adminDSN = testdb.ResolveAdminDSN(cfg, defaultCockroachDSN)
+	if err := testdb.CheckProductionGuard(cfg, p.adminDSN); err != nil {
+		return err
+	}
+
+	config, err := pgx.ParseConfig(p.adminDSN)
+	if err != nil {
+		return fmt.Errorf("parse admin DSN: %w", err)
+	}
+	if cfg.TLSConfig != nil {
+		tlsConfig := cfg.TLSConfig.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = config.Host
+		}
+		config.TLSConfig = tlsConfig
+		config.Fallbacks = nil
+	}
+
+	p.conn, err = pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("connect to admin database: %w", err)
+	}
+
+	return nil
+}
+
+// checkSupported returns an error wrapping ErrUnsupportedOption for the first
+// option set in cfg that CockroachProvider doesn't support.
+func checkSupported(cfg testdb.Config) error {
+	if cfg.Isolation == testdb.IsolationSchema {
+		return fmt.Errorf("%w: %w: schema isolation", ErrUnsupportedOption, testdb.ErrIsolationNotSupported)
+	}
+
+	unsupported := []struct {
+		option string
+		set    bool
+	}{
+		{"WithDBOwner", cfg.DBOwner != ""},
+		{"WithRoles", len(cfg.Roles) > 0},
+		{"WithTemplate", cfg.Template != ""},
+		{"WithAppName", cfg.AppName != ""},
+	}
+	for _, opt := range unsupported {
+		if opt.set {
+			return fmt.Errorf("%w: %s", ErrUnsupportedOption, opt.option)
+		}
+	}
+	return nil
+}
+
+// CreateDatabase creates a new CockroachDB database with the given name. An
+// existing database with the name (SQLSTATE 42P04) gives an error wrapping
+// testdb.ErrDatabaseExists.
+func (p *CockroachProvider) CreateDatabase(ctx context.Context, name string) error {
+	if _, err := p.conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "42P04" {
+			return fmt.Errorf("create database: %w: %w", testdb.ErrDatabaseExists, err)
+		}
+		return fmt.Errorf("create database: %w", err)
+	}
+	return nil
+}
+
+// DropDatabase drops a CockroachDB database and everything in it, if it exists.
+// Unlike PostgreSQL, CockroachDB doesn't refuse to drop a database that still
+// has sessions connected to it, so no retries are needed.
+func (p *CockroachProvider) DropDatabase(ctx context.Context, name string) error {
+	if _, err := p.conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{name}.Sanitize()+" CASCADE"); err != nil {
+		return fmt.Errorf("drop database: %w", err)
+	}
+	return nil
+}
+
+// TerminateConnections cancels the sessions connected to the test database.
+//
+// CockroachDB doesn't report which database a session uses, so BuildDSN sets
+// application_name to the database name and the sessions are found by
+// application name in SHOW CLUSTER SESSIONS.
+func (p *CockroachProvider) TerminateConnections(ctx context.Context, name string) error {
+	_, err := p.conn.Exec(ctx, `
+        CANCEL SESSIONS IF EXISTS (
+            SELECT session_id FROM [SHOW CLUSTER SESSIONS]
+            WHERE application_name = $1
+        )
+    `, name)
+	if err != nil {
+		return fmt.Errorf("cancel sessions: %w", err)
+	}
+	return nil
+}
+
+// BuildDSN constructs the connection string for the test database: the admin
+// DSN with the database replaced and application_name set to the database
+// name (see TerminateConnections). Other connection parameters, such as TLS
+// certificates, are kept.
+func (p *CockroachProvider) BuildDSN(dbName string) (string, error) {
+	if p.adminDSN == "" {
+		return "", fmt.Errorf("provider not initialized")
+	}
+	return buildDSN(p.adminDSN, dbName)
+}
+
+// buildDSN implements BuildDSN for a URL-format admin DSN.
+func buildDSN(adminDSN, dbName string) (string, error) {
+	u, err := url.Parse(adminDSN)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return "", fmt.Errorf("admin DSN must be a postgres:// or postgresql:// URL")
+	}
+
+	u.Scheme = "postgres"
+	u.Path = "/" + dbName
+	u.RawPath = ""
+
+	query := u.Query()
+	query.Set("application_name", dbName)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// ExecAdmin runs sql with args on the admin connection. It implements
+// testdb.AdminExecer for the hooks set with testdb.WithBeforeCreate and
+// testdb.WithAfterCreate.
+func (p *CockroachProvider) ExecAdmin(ctx context.Context, sql string, args ...any) error {
+	if p.conn == nil {
+		return fmt.Errorf("provider not initialized")
+	}
+	_, err := p.conn.Exec(ctx, sql, args...)
+	return err
+}
+
+// ResolvedAdminDSN returns the resolved admin DSN being used by this provider.
+func (p *CockroachProvider) ResolvedAdminDSN() string {
+	return p.adminDSN
+}
+
+// Cleanup closes the admin connection.
+func (p *CockroachProvider) Cleanup(ctx context.Context) error {
+	if p.conn != nil {
+		return p.conn.Close(ctx)
+	}
+	return nil
+}
+
+// Setup creates a CockroachDB test database and returns a ready-to-use
+// connection pool, like postgres.Setup. Migrations run if configured, and
+// cleanup is registered via t.Cleanup().
+//
+// Calls t.Fatal() on any error.
+func Setup(t testing.TB, opts ...testdb.Option) *pgxpool.Pool {
+	t.Helper()
+	db := newDatabase(t, "cockroach.Setup", &postgres.PoolInitializer{}, opts...)
+	return db.Entity().(*pgxpool.Pool)
+}
+
+// New creates a CockroachDB test database with a custom initializer, like
+// postgres.New. Any initializer for PostgreSQL works, e.g. the postgres and
+// postgres/initializers packages' initializers.
+//
+// Calls t.Fatal() on any error.
+func New(t testing.TB, initializer testdb.DBInitializer, opts ...testdb.Option) *testdb.TestDatabase {
+	t.Helper()
+	return newDatabase(t, "cockroach.New", initializer, opts...)
+}
+
+// newDatabase implements Setup and New. callerName prefixes fatal errors.
+func newDatabase(t testing.TB, callerName string, initializer testdb.DBInitializer, opts ...testdb.Option) *testdb.TestDatabase {
+	t.Helper()
+
+	if initializer == nil {
+		t.Fatalf("%s: initializer cannot be nil", callerName)
+	}
+
+	db, err := testdb.New(t, &CockroachProvider{}, initializer, opts...)
+	if err != nil {
+		t.Fatalf("%s: %v", callerName, err)
+	}
+
+	if db.Config().MigrationDir != "" && !db.Config().MigrateBeforeInit {
+		if err := db.RunMigrations(); err != nil {
+			closeEntity(t, db)
+			if closeErr := db.Close(); closeErr != nil {
+				t.Logf("Warning: failed to close database after migration error: %v", closeErr)
+			}
+			t.Fatalf("%s: migrations failed: %v", callerName, err)
+		}
+	}
+
+	if db.Config().AutoMigrate {
+		if err := db.RunAutoMigrate(); err != nil {
+			closeEntity(t, db)
+			if closeErr := db.Close(); closeErr != nil {
+				t.Logf("Warning: failed to close database after auto-migration error: %v", closeErr)
+			}
+			t.Fatalf("%s: auto-migration failed: %v", callerName, err)
+		}
+	}
+
+	t.Cleanup(func() {
+		closeEntity(t, db)
+		if err := db.Close(); err != nil {
+			t.Errorf("testdb cleanup failed: %v", err)
+		}
+	})
+
+	return db
+}
+
+// closeEntity closes the test database's entity (see testdb.CloseEntity), so
+// the *pgxpool.Pool from Setup is closed too. A testdb.WithLazyCreate
+// database that was never created is left alone.
+func closeEntity(t testing.TB, db *testdb.TestDatabase) {
+	if !db.Created() {
+		return
+	}
+	if err := testdb.CloseEntity(db.Entity()); err != nil {
+		t.Logf("Warning: failed to close entity: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package cockroach_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/cockroach"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// cockroachAdminDSN returns the admin DSN of the CockroachDB cluster used for
+// integration tests, skipping the test if none is configured.
+func cockroachAdminDSN(t *testing.T) string {
+	t.Helper()
+	dsn := os.Getenv("TEST_COCKROACH_URL")
+	if dsn == "" {
+		t.Skip("TEST_COCKROACH_URL not set")
+	}
+	return dsn
+}
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	pool := cockroach.Setup(t, testdb.WithAdminDSN(cockroachAdminDSN(t)))
+
+	if _, err := pool.Exec(ctx, "CREATE TABLE users (id INT PRIMARY KEY, email STRING)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if _, err := pool.Exec(ctx, "INSERT INTO users VALUES (1, 'test@example.com')"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+}
+
+func TestInitializeUnsupportedOptions(t *testing.T) {
+	tests := map[string]struct {
+		cfg     testdb.Config
+		wantErr error
+	}{
+		"schema isolation": {cfg: testdb.Config{Isolation: testdb.IsolationSchema}, wantErr: testdb.ErrIsolationNotSupported},
+		"WithDBOwner":      {cfg: testdb.Config{DBOwner: "app"}},
+		"WithRoles":        {cfg: testdb.Config{Roles: []testdb.RoleSpec{{Name: "app"}}}},
+		"WithTemplate":     {cfg: testdb.Config{Template: "app_template"}},
+		"WithAppName":      {cfg: testdb.Config{AppName: "billing"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := &cockroach.CockroachProvider{}
+			err := p.Initialize(context.Background(), tc.cfg)
+			if !errors.Is(err, cockroach.ErrUnsupportedOption) {
+				t.Fatalf("expected ErrUnsupportedOption, got %v", err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCleanupDropsDatabase(t *testing.T) {
+	ctx := context.Background()
+	adminDSN := cockroachAdminDSN(t)
+
+	db, err := testdb.New(t, &cockroach.CockroachProvider{}, nil, testdb.WithAdminDSN(adminDSN))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// A connection left open must not prevent the drop
+	pool, err := pgxpool.New(ctx, db.DSN())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer pool.Close()
+	if err := pool.Ping(ctx); err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	admin, err := pgxpool.New(ctx, adminDSN)
+	if err != nil {
+		t.Fatalf("failed to connect as admin: %v", err)
+	}
+	defer admin.Close()
+
+	var count int
+	if err := admin.QueryRow(ctx, "SELECT count(*) FROM [SHOW DATABASES] WHERE database_name = $1", db.Name()).Scan(&count); err != nil {
+		t.Fatalf("failed to list databases: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected database %s to be dropped", db.Name())
+	}
+}
+
+// modelEntity is an ORM-style entity that creates its schema in AutoMigrate.
+type modelEntity struct {
+	*pgxpool.Pool
+}
+
+func (e *modelEntity) AutoMigrate() error {
+	_, err := e.Exec(context.Background(), "CREATE TABLE IF NOT EXISTS widgets (id INT PRIMARY KEY, name STRING)")
+	return err
+}
+
+type modelInitializer struct {
+	postgres.PoolInitializer
+}
+
+func (m *modelInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
+	pool, err := m.PoolInitializer.InitializeTestDatabase(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &modelEntity{Pool: pool.(*pgxpool.Pool)}, nil
+}
+
+func TestNewAutoMigrate(t *testing.T) {
+	db := cockroach.New(t, &modelInitializer{},
+		testdb.WithAdminDSN(cockroachAdminDSN(t)),
+		testdb.WithAutoMigrate())
+	entity := db.Entity().(*modelEntity)
+
+	var exists bool
+	err := entity.QueryRow(context.Background(), "SELECT to_regclass('widgets') IS NOT NULL").Scan(&exists)
+	if err != nil {
+		t.Fatalf("failed to check for table: %v", err)
+	}
+	if !exists {
+		t.Error("expected AutoMigrate to create the widgets table")
+	}
+}
@@ -0,0 +1,77 @@
+package testdb
+
+import (
+	"context"
+	"sync"
+)
+
+// databaseSlots counts the test databases live in the process. It's shared by
+// every New() call, whatever MaxConcurrent it was configured with.
+var databaseSlots struct {
+	mu sync.Mutex
+
+	// live is the number of test databases holding a slot.
+	live int
+
+	// released is closed, and reset to nil, when a slot is released, waking
+	// every New() waiting for one. It's nil while nobody waits.
+	released chan struct{}
+}
+
+// acquireDatabaseSlot blocks until fewer than max test databases are live in
+// the process, then claims a slot. The returned function releases the slot and
+// is safe to call more than once. A max of 0 means no limit; the slot is still
+// counted against the limits of other New() calls.
+//
+// If logf is non-nil, it's called once when New() has to wait for a slot.
+// Waiting stops with ctx's error if ctx is done first.
+func acquireDatabaseSlot(ctx context.Context, max int, logf func(format string, args ...any)) (release func(), err error) {
+	logged := false
+	for {
+		databaseSlots.mu.Lock()
+		if max <= 0 || databaseSlots.live < max {
+			databaseSlots.live++
+			databaseSlots.mu.Unlock()
+			break
+		}
+		if databaseSlots.released == nil {
+			databaseSlots.released = make(chan struct{})
+		}
+		released := databaseSlots.released
+		databaseSlots.mu.Unlock()
+
+		if logf != nil && !logged {
+			logf("testdb: waiting for a database slot (max %d concurrent)", max)
+			logged = true
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(releaseDatabaseSlot)
+	}, nil
+}
+
+// releaseDatabaseSlot frees a slot claimed by acquireDatabaseSlot.
+func releaseDatabaseSlot() {
+	databaseSlots.mu.Lock()
+	defer databaseSlots.mu.Unlock()
+
+	databaseSlots.live--
+	if databaseSlots.released != nil {
+		close(databaseSlots.released)
+		databaseSlots.released = nil
+	}
+}
+
+// liveDatabases returns the number of test databases holding a slot.
+func liveDatabases() int {
+	databaseSlots.mu.Lock()
+	defer databaseSlots.mu.Unlock()
+	return databaseSlots.live
+}
@@ -0,0 +1,151 @@
+package testdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithMaxConcurrent(t *testing.T) {
+	cfg := DefaultConfig()
+	opt := WithMaxConcurrent(5)
+	opt(&cfg)
+
+	if cfg.MaxConcurrent != 5 {
+		t.Errorf("Expected MaxConcurrent 5, got %d", cfg.MaxConcurrent)
+	}
+}
+
+func TestNewWithNegativeMaxConcurrent(t *testing.T) {
+	_, err := New(t, &mockProvider{}, nil, WithMaxConcurrent(-1))
+	if !errors.Is(err, ErrInvalidMaxConcurrent) {
+		t.Fatalf("Expected ErrInvalidMaxConcurrent, got %v", err)
+	}
+}
+
+func TestMaxConcurrentBlocksUntilClose(t *testing.T) {
+	// Slots are counted process-wide, including databases other tests still hold
+	const free = 3
+	opts := []Option{WithMaxConcurrent(liveDatabases() + free)}
+
+	var held []*TestDatabase
+	for range free {
+		db, err := New(t, &mockProvider{}, nil, opts...)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		held = append(held, db)
+	}
+
+	created := make(chan *TestDatabase)
+	go func() {
+		db, err := New(t, &mockProvider{}, nil, opts...)
+		if err != nil {
+			t.Errorf("New failed: %v", err)
+		}
+		created <- db
+	}()
+
+	select {
+	case <-created:
+		t.Fatal("Expected New to block while the limit is reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := held[0].Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var db *TestDatabase
+	select {
+	case db = <-created:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected New to proceed after a database was closed")
+	}
+
+	for _, d := range append(held[1:], db) {
+		if err := d.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	}
+}
+
+func TestMaxConcurrentReleasedOnError(t *testing.T) {
+	limit := liveDatabases() + 2
+
+	for range limit + 1 {
+		provider := &mockErrorProvider{failCreate: true}
+		if _, err := New(t, provider, nil, WithMaxConcurrent(limit)); err == nil {
+			t.Fatal("Expected CreateDatabase error")
+		}
+	}
+
+	// All slots must have been released, so this doesn't block
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		db, err := New(t, &mockProvider{}, nil, WithMaxConcurrent(limit))
+		if err != nil {
+			t.Errorf("New failed: %v", err)
+			return
+		}
+		_ = db.Close()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected slots to be released after failed New calls")
+	}
+}
+
+func TestMaxConcurrentSharedAcrossLimits(t *testing.T) {
+	limit := liveDatabases() + 2
+
+	// A database created without a limit still takes a slot
+	unlimited, err := New(t, &mockProvider{}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// A different limit counts the same databases
+	other, err := New(t, &mockProvider{}, nil, WithMaxConcurrent(limit+1))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	created := make(chan *TestDatabase)
+	go func() {
+		db, err := New(t, &mockProvider{}, nil, WithMaxConcurrent(limit))
+		if err != nil {
+			t.Errorf("New failed: %v", err)
+		}
+		created <- db
+	}()
+
+	select {
+	case <-created:
+		t.Fatal("Expected New to block while the limit is reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := unlimited.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var db *TestDatabase
+	select {
+	case db = <-created:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected New to proceed after the unlimited database was closed")
+	}
+
+	for _, d := range []*TestDatabase{other, db} {
+		if err := d.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	}
+	if got := liveDatabases(); got != limit-2 {
+		t.Errorf("Expected %d live databases after Close, got %d", limit-2, got)
+	}
+}
@@ -0,0 +1,269 @@
+// Package mongo provides MongoDB test database support for testdb.
+//
+// Each test gets its own database on a shared MongoDB deployment, reached with
+// the official Go driver. MongoDB creates a database on its first write, so
+// CreateDatabase only reserves the name and DropDatabase drops whatever the
+// test wrote. Migrations run with golang-migrate (testdb.MigrationToolMigrate),
+// whose mongodb driver accepts the test database URI built by BuildDSN.
+//
+// Schema isolation, WithDBOwner, WithRoles, WithTemplate, WithAppName and the
+// postgres package's options are not supported.
+//
+// # Basic Usage
+//
+//	func TestUsers(t *testing.T) {
+//	    db := mongo.Setup(t, testdb.WithAdminDSN("mongodb://localhost:27017"))
+//
+//	    _, err := db.Collection("users").InsertOne(ctx, bson.M{"email": "test@example.com"})
+//	    require.NoError(t, err)
+//	}
+//
+// The admin URI is resolved from WithAdminDSN, then the TEST_MONGODB_URL
+// environment variable, defaulting to a local deployment without
+// authentication. TEST_DATABASE_URL and DATABASE_URL aren't used, since they
+// usually point to a SQL database.
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defaultMongoURI is the admin URI of a local MongoDB deployment without
+// authentication.
+const defaultMongoURI = "mongodb://localhost:27017"
+
+// MongoProvider implements testdb.Provider for MongoDB.
+type MongoProvider struct {
+	client   *mongo.Client // Admin client
+	adminURI string        // Resolved admin URI, used for BuildDSN
+}
+
+// resolveAdminURI resolves the admin URI: cfg.AdminDSNOverride (WithAdminDSN
+// or WithAdminDSNResolver), then TEST_MONGODB_URL, then defaultMongoURI.
+func resolveAdminURI(cfg testdb.Config) string {
+	if cfg.AdminDSNOverride != "" {
+		return cfg.AdminDSNOverride
+	}
+	if uri := os.Getenv("TEST_MONGODB_URL"); uri != "" {
+		return uri
+	}
+	return defaultMongoURI
+}
+
+// Initialize connects to the MongoDB deployment and checks that it is
+// reachable.
+func (p *MongoProvider) Initialize(ctx context.Context, cfg testdb.Config) error {
+	p.adminURI = resolveAdminURI(cfg)
+	if err := testdb.CheckProductionGuard(cfg, p.adminURI); err != nil {
+		return err
+	}
+
+	clientOpts := options.Client().ApplyURI(p.adminURI)
+	if cfg.TLSConfig != nil {
+		clientOpts.SetTLSConfig(cfg.TLSConfig.Clone())
+	}
+
+	client, err := mongo.Connect(clientOpts)
+	if err != nil {
+		return fmt.Errorf("connect to admin deployment: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.WithoutCancel(ctx)) // Best effort cleanup
+		return fmt.Errorf("ping admin deployment: %w", err)
+	}
+
+	p.client = client
+	return nil
+}
+
+// CreateDatabase reserves the database name. MongoDB creates a database on the
+// first write to one of its collections, so there is nothing to create yet.
+func (p *MongoProvider) CreateDatabase(ctx context.Context, name string) error {
+	if p.client == nil {
+		return fmt.Errorf("provider not initialized")
+	}
+	return nil
+}
+
+// DropDatabase drops the database and all of its collections. Dropping a
+// database that was never written to succeeds.
+func (p *MongoProvider) DropDatabase(ctx context.Context, name string) error {
+	if err := p.client.Database(name).Drop(ctx); err != nil {
+		return fmt.Errorf("drop database: %w", err)
+	}
+	return nil
+}
+
+// TerminateConnections does nothing: MongoDB connections aren't bound to a
+// database, and a database can be dropped while clients are connected.
+func (p *MongoProvider) TerminateConnections(ctx context.Context, name string) error {
+	return nil
+}
+
+// BuildDSN constructs the connection URI for the test database: the admin URI
+// with the database path replaced. Other options, such as the replica set or
+// TLS settings, are kept.
+func (p *MongoProvider) BuildDSN(dbName string) (string, error) {
+	if p.adminURI == "" {
+		return "", fmt.Errorf("provider not initialized")
+	}
+	return buildDSN(p.adminURI, dbName)
+}
+
+// buildDSN implements BuildDSN. MongoDB authenticates against the database in
+// the URI path unless authSource is set, so when the admin URI has credentials
+// authSource is pinned to the database they were authenticating against
+// (its path, or "admin").
+func buildDSN(adminURI, dbName string) (string, error) {
+	u, err := url.Parse(adminURI)
+	if err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") {
+		return "", fmt.Errorf("admin URI must be a mongodb:// or mongodb+srv:// URL")
+	}
+
+	query := u.Query()
+	if u.User != nil && query.Get("authSource") == "" {
+		authSource := "admin"
+		if path := u.Path; len(path) > 1 {
+			authSource = path[1:]
+		}
+		query.Set("authSource", authSource)
+	}
+	u.RawQuery = query.Encode()
+
+	u.Path = "/" + dbName
+	u.RawPath = ""
+
+	return u.String(), nil
+}
+
+// ResolvedAdminDSN returns the resolved admin URI being used by this provider.
+func (p *MongoProvider) ResolvedAdminDSN() string {
+	return p.adminURI
+}
+
+// Cleanup disconnects the admin client.
+func (p *MongoProvider) Cleanup(ctx context.Context) error {
+	if p.client != nil {
+		return p.client.Disconnect(ctx)
+	}
+	return nil
+}
+
+// MongoInitializer connects to the test database and returns it as a
+// *mongo.Database. Disconnect it with db.Client().Disconnect(ctx); Setup and
+// New do this during cleanup.
+type MongoInitializer struct {
+	// ClientOptions, if set, is called with the client options before
+	// connecting, after the test database URI has been applied.
+	ClientOptions func(*options.ClientOptions)
+}
+
+// InitializeTestDatabase implements testdb.DBInitializer.
+func (m *MongoInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse URI: %w", err)
+	}
+	dbName := u.Path
+	if len(dbName) > 0 {
+		dbName = dbName[1:]
+	}
+	if dbName == "" {
+		return nil, fmt.Errorf("URI has no database")
+	}
+
+	clientOpts := options.Client().ApplyURI(dsn)
+	if cfg, ok := testdb.ConfigFromContext(ctx); ok && cfg.TLSConfig != nil {
+		clientOpts.SetTLSConfig(cfg.TLSConfig.Clone())
+	}
+	if m.ClientOptions != nil {
+		m.ClientOptions(clientOpts)
+	}
+
+	client, err := mongo.Connect(clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.WithoutCancel(ctx)) // Best effort cleanup
+		return nil, fmt.Errorf("ping: %w", err)
+	}
+
+	return client.Database(dbName), nil
+}
+
+// Setup creates a MongoDB test database and returns it, ready to use.
+// Migrations run if configured, and cleanup is registered via t.Cleanup().
+//
+// Calls t.Fatal() on any error.
+func Setup(t testing.TB, opts ...testdb.Option) *mongo.Database {
+	t.Helper()
+	db := New(t, &MongoInitializer{}, opts...)
+	return db.Entity().(*mongo.Database)
+}
+
+// New creates a MongoDB test database with a custom initializer. If the
+// initializer's entity is a *mongo.Database or *mongo.Client, it is
+// disconnected during cleanup.
+//
+// Calls t.Fatal() on any error.
+func New(t testing.TB, initializer testdb.DBInitializer, opts ...testdb.Option) *testdb.TestDatabase {
+	t.Helper()
+
+	if initializer == nil {
+		t.Fatalf("mongo.New: initializer cannot be nil")
+	}
+
+	db, err := testdb.New(t, &MongoProvider{}, initializer, opts...)
+	if err != nil {
+		t.Fatalf("mongo.New: %v", err)
+	}
+
+	if db.Config().MigrationDir != "" && !db.Config().MigrateBeforeInit {
+		if err := db.RunMigrations(); err != nil {
+			disconnectEntity(t, db)
+			if closeErr := db.Close(); closeErr != nil {
+				t.Logf("Warning: failed to close database after migration error: %v", closeErr)
+			}
+			t.Fatalf("mongo.New: migrations failed: %v", err)
+		}
+	}
+
+	t.Cleanup(func() {
+		disconnectEntity(t, db)
+		if err := db.Close(); err != nil {
+			t.Errorf("testdb cleanup failed: %v", err)
+		}
+	})
+
+	return db
+}
+
+// disconnectEntity disconnects the client of the test database's entity, if
+// it is a *mongo.Database or *mongo.Client. A testdb.WithLazyCreate database
+// that was never created is left alone.
+func disconnectEntity(t testing.TB, db *testdb.TestDatabase) {
+	if !db.Created() {
+		return
+	}
+	var client *mongo.Client
+	switch entity := db.Entity().(type) {
+	case *mongo.Database:
+		client = entity.Client()
+	case *mongo.Client:
+		client = entity
+	default:
+		return
+	}
+	if err := client.Disconnect(context.Background()); err != nil {
+		t.Logf("Warning: failed to disconnect client: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/mongo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	mongodriver "go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// mongoAdminURI returns the admin URI of the MongoDB deployment used for
+// integration tests, skipping the test if none is configured.
+func mongoAdminURI(t *testing.T) string {
+	t.Helper()
+	uri := os.Getenv("TEST_MONGODB_URL")
+	if uri == "" {
+		t.Skip("TEST_MONGODB_URL not set")
+	}
+	return uri
+}
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	db := mongo.Setup(t, testdb.WithAdminDSN(mongoAdminURI(t)))
+
+	if _, err := db.Collection("users").InsertOne(ctx, bson.M{"email": "test@example.com"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	count, err := db.Collection("users").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("failed to count: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 document, got %d", count)
+	}
+}
+
+func TestCleanupDropsDatabase(t *testing.T) {
+	ctx := context.Background()
+	adminURI := mongoAdminURI(t)
+
+	db, err := testdb.New(t, &mongo.MongoProvider{}, &mongo.MongoInitializer{}, testdb.WithAdminDSN(adminURI))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// Writing creates the database
+	testDB := db.Entity().(*mongodriver.Database)
+	if _, err := testDB.Collection("users").InsertOne(ctx, bson.M{"email": "test@example.com"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	defer func() { _ = testDB.Client().Disconnect(ctx) }()
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	admin, err := mongodriver.Connect(options.Client().ApplyURI(adminURI))
+	if err != nil {
+		t.Fatalf("failed to connect as admin: %v", err)
+	}
+	defer func() { _ = admin.Disconnect(ctx) }()
+
+	names, err := admin.ListDatabaseNames(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("failed to list databases: %v", err)
+	}
+	if slices.Contains(names, db.Name()) {
+		t.Errorf("expected database %s to be dropped", db.Name())
+	}
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// adminLockKey returns the pg_advisory_lock key serializing CREATE and DROP
+// DATABASE for test databases with the given prefix (testdb.WithParallelSafeAdmin).
+func adminLockKey(prefix string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("testdb:" + prefix))
+	return int64(h.Sum64())
+}
+
+// execSerialized runs sql on the admin connection. With
+// testdb.WithParallelSafeAdmin it holds the provider's advisory lock while
+// doing so; advisory locks belong to a session, so with the shared admin pool
+// a single pooled connection is used for locking, running sql and unlocking.
+func (p *PostgresProvider) execSerialized(ctx context.Context, sql string) error {
+	if !p.parallelSafe {
+		_, err := p.admin.Exec(ctx, sql)
+		return err
+	}
+
+	conn := p.admin
+	if pool, ok := p.admin.(*pgxpool.Pool); ok {
+		pooled, err := pool.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("acquire admin connection: %w", err)
+		}
+		defer pooled.Release()
+		conn = pooled
+	}
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", p.lockKey); err != nil {
+		return fmt.Errorf("acquire advisory lock: %w", err)
+	}
+	defer func() {
+		// Unlock even if ctx was canceled, or the lock stays held until the
+		// session ends
+		_, _ = conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", p.lockKey)
+	}()
+
+	_, err := conn.Exec(ctx, sql)
+	return err
+}
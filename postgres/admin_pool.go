@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// adminConn is the subset of *pgx.Conn and *pgxpool.Pool used for admin
+// operations, so the provider can run them over either a dedicated connection
+// or the shared admin pool.
+type adminConn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+var (
+	// sharedAdminPoolsMu guards sharedAdminPools.
+	sharedAdminPoolsMu sync.Mutex
+
+	// sharedAdminPools holds the process-wide admin pools used with
+	// testdb.WithSharedAdminPool, keyed by admin DSN and TLS config.
+	sharedAdminPools = map[sharedAdminPoolKey]*pgxpool.Pool{}
+)
+
+// sharedAdminPoolKey identifies a shared admin pool. Test databases configured
+// with different tls.Configs (testdb.WithTLSConfig) or connect timeouts
+// (testdb.WithConnectTimeout) don't share a pool.
+type sharedAdminPoolKey struct {
+	adminDSN       string
+	tlsConfig      *tls.Config
+	connectTimeout time.Duration
+}
+
+// sharedAdminPool returns the shared admin pool for adminDSN and tlsConfig,
+// creating it on first use. The pool size follows pgx's defaults unless the
+// DSN sets pool_max_conns. It always uses the DSN's credentials, since
+// testdb.WithCredentialProvider can't be combined with
+// testdb.WithSharedAdminPool.
+func sharedAdminPool(ctx context.Context, adminDSN string, tlsConfig *tls.Config, connectTimeout time.Duration) (*pgxpool.Pool, error) {
+	sharedAdminPoolsMu.Lock()
+	defer sharedAdminPoolsMu.Unlock()
+
+	key := sharedAdminPoolKey{adminDSN: adminDSN, tlsConfig: tlsConfig, connectTimeout: connectTimeout}
+	if pool, ok := sharedAdminPools[key]; ok {
+		return pool, nil
+	}
+
+	config, err := pgxpool.ParseConfig(adminDSN)
+	if err != nil {
+		return nil, fmt.Errorf("create shared admin pool: %w", err)
+	}
+	applyTLSConfig(config.ConnConfig, tlsConfig)
+	applyAdminAppName(config.ConnConfig, adminDSN)
+	if connectTimeout > 0 {
+		config.ConnConfig.ConnectTimeout = connectTimeout
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("create shared admin pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("connect to admin database: %w", err)
+	}
+
+	sharedAdminPools[key] = pool
+	return pool, nil
+}
+
+// CloseSharedAdminPools closes the admin pools created for
+// testdb.WithSharedAdminPool. Call it from TestMain after m.Run() to release
+// the connections before the test binary exits; test databases created
+// afterwards open a new pool.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//	    code := m.Run()
+//	    postgres.CloseSharedAdminPools()
+//	    os.Exit(code)
+//	}
+func CloseSharedAdminPools() {
+	sharedAdminPoolsMu.Lock()
+	defer sharedAdminPoolsMu.Unlock()
+
+	for key, pool := range sharedAdminPools {
+		pool.Close()
+		delete(sharedAdminPools, key)
+	}
+}
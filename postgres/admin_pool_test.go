@@ -0,0 +1,85 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestSharedAdminPool(t *testing.T) {
+	t.Cleanup(postgres.CloseSharedAdminPools)
+
+	for i := range 10 {
+		t.Run(fmt.Sprintf("db_%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			pool := postgres.Setup(t, testdb.WithSharedAdminPool())
+
+			var result int
+			if err := pool.QueryRow(context.Background(), "SELECT 1").Scan(&result); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestSharedAdminPoolCleanupDropsDatabase(t *testing.T) {
+	t.Cleanup(postgres.CloseSharedAdminPools)
+
+	ctx := context.Background()
+	provider := &postgres.PostgresProvider{}
+
+	db, err := testdb.New(t, provider, nil, testdb.WithSharedAdminPool())
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	name := db.Name()
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+
+	// The shared pool must still be usable after a provider is cleaned up
+	other := &postgres.PostgresProvider{}
+	if err := other.Initialize(ctx, testdb.Config{SharedAdminPool: true}); err != nil {
+		t.Fatalf("failed to initialize provider with shared pool: %v", err)
+	}
+	defer func() { _ = other.Cleanup(ctx) }()
+
+	// Recreating the same name succeeds only if cleanup dropped it
+	if err := other.CreateDatabase(ctx, name); err != nil {
+		t.Fatalf("expected database to be dropped and shared pool usable, got: %v", err)
+	}
+	if err := other.DropDatabase(ctx, name); err != nil {
+		t.Fatalf("failed to drop database: %v", err)
+	}
+}
+
+func TestParallelSafeAdmin(t *testing.T) {
+	t.Cleanup(postgres.CloseSharedAdminPools)
+
+	tests := map[string][]testdb.Option{
+		"admin connection":  {testdb.WithParallelSafeAdmin()},
+		"shared admin pool": {testdb.WithParallelSafeAdmin(), testdb.WithSharedAdminPool()},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i := range 5 {
+				t.Run(fmt.Sprintf("db_%d", i), func(t *testing.T) {
+					t.Parallel()
+
+					pool := postgres.Setup(t, opts...)
+
+					var result int
+					if err := pool.QueryRow(context.Background(), "SELECT 1").Scan(&result); err != nil {
+						t.Fatalf("query failed: %v", err)
+					}
+				})
+			}
+		})
+	}
+}
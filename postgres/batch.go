@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExecBatch sends the statements to the database in a single round-trip using a
+// pgx batch, which is much faster than calling Exec in a loop when setting up
+// many small DDL/DML statements.
+//
+// Each entry must be a single SQL statement without parameters. The batch runs
+// as one implicit transaction: if any statement fails, none of them take effect
+// and the returned error identifies the failing statement by its 1-based
+// position in statements.
+//
+// Example:
+//
+//	err := postgres.ExecBatch(ctx, pool, []string{
+//	    "CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT NOT NULL)",
+//	    "INSERT INTO users (email) VALUES ('alice@example.com')",
+//	    "INSERT INTO users (email) VALUES ('bob@example.com')",
+//	})
+func ExecBatch(ctx context.Context, pool *pgxpool.Pool, statements []string) error {
+	if len(statements) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, stmt := range statements {
+		batch.Queue(stmt)
+	}
+
+	results := pool.SendBatch(ctx, batch)
+	for i, stmt := range statements {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close() // Best effort cleanup
+			return fmt.Errorf("batch statement %d of %d (%s): %w", i+1, len(statements), stmt, err)
+		}
+	}
+
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("close batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestExecBatch(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	err := postgres.ExecBatch(ctx, pool, []string{
+		"CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT NOT NULL UNIQUE)",
+		"INSERT INTO users (email) VALUES ('alice@example.com')",
+		"INSERT INTO users (email) VALUES ('bob@example.com')",
+	})
+	if err != nil {
+		t.Fatalf("ExecBatch failed: %v", err)
+	}
+
+	var count int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&count); err != nil {
+		t.Fatalf("failed to count users: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 users, got %d", count)
+	}
+}
+
+func TestExecBatchEmpty(t *testing.T) {
+	pool := postgres.Setup(t)
+
+	if err := postgres.ExecBatch(context.Background(), pool, nil); err != nil {
+		t.Fatalf("expected no error for empty batch, got %v", err)
+	}
+}
+
+func TestExecBatchReportsFailingStatement(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	if _, err := pool.Exec(ctx, "CREATE TABLE users (email TEXT NOT NULL UNIQUE)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	err := postgres.ExecBatch(ctx, pool, []string{
+		"INSERT INTO users (email) VALUES ('alice@example.com')",
+		"INSERT INTO users (email) VALUES ('alice@example.com')",
+		"INSERT INTO users (email) VALUES ('carol@example.com')",
+	})
+	if err == nil {
+		t.Fatal("expected unique violation error")
+	}
+	if !strings.Contains(err.Error(), "batch statement 2 of 3") {
+		t.Errorf("expected error to identify statement 2, got: %v", err)
+	}
+
+	// The batch runs in one implicit transaction, so nothing was inserted
+	var count int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&count); err != nil {
+		t.Fatalf("failed to count users: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected failed batch to be rolled back, got %d rows", count)
+	}
+}
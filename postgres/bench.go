@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SetupB creates a PostgreSQL test database for a benchmark and returns a
+// ready-to-use connection pool along with a reset function.
+//
+// The database is created (and migrated, if configured) once per benchmark
+// run, outside the measured region: SetupB calls b.ResetTimer() before
+// returning. Cleanup is registered via b.Cleanup(), as with Setup.
+//
+// The reset function truncates every table in the pool's search_path schemas
+// (restarting identity sequences), except the migration tools' bookkeeping
+// tables. It stops the benchmark timer while it runs, so calling it between
+// iterations doesn't count towards the measurement. Use it when each iteration
+// needs an empty database, instead of recreating the database per iteration.
+//
+// Calls b.Fatal() on any error.
+//
+// Example:
+//
+//	func BenchmarkCreateUser(b *testing.B) {
+//	    pool, reset := postgres.SetupB(b,
+//	        testdb.WithMigrations("./migrations"),
+//	        testdb.WithMigrationTool(testdb.MigrationToolTern))
+//
+//	    for b.Loop() {
+//	        if err := CreateUser(ctx, pool, "test@example.com"); err != nil {
+//	            b.Fatal(err)
+//	        }
+//	        reset()
+//	    }
+//	}
+func SetupB(b *testing.B, opts ...testdb.Option) (*pgxpool.Pool, func()) {
+	b.Helper()
+
+	pool := setup(context.Background(), b, "postgres.SetupB", &PostgresProvider{}, opts...)
+
+	cfg := testdb.DefaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	reset := func() {
+		b.Helper()
+		b.StopTimer()
+		defer b.StartTimer()
+
+		if err := truncateTables(context.Background(), pool, cfg.MigrationTable); err != nil {
+			b.Fatalf("postgres.SetupB: reset: %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	return pool, reset
+}
+
+// BenchDB is a benchmark database that can be reset to its migrated state by
+// re-cloning it from a template. Create it with BenchmarkDB.
+type BenchDB struct {
+	b     *testing.B
+	pool  *pgxpool.Pool
+	name  string
+	admin *PostgresProvider
+}
+
+// BenchmarkDB creates a PostgreSQL database for a benchmark whose state can
+// be reset between iterations with ResetFromTemplate, which drops the database
+// and clones it again from a migrated template. For schemas with many tables,
+// cloning is faster than truncating them all (as SetupB's reset does), and it
+// also restores rows seeded by migrations and the schema itself.
+//
+// opts configure both databases as with Setup: the template is created,
+// restored (WithRestoreFrom) and migrated once, and the benchmark database is
+// cloned from it, so migrations aren't run again. Both are dropped via
+// b.Cleanup(), and b.ResetTimer() is called before returning. Not supported
+// with schema isolation, since a schema can't be cloned.
+//
+// Calls b.Fatal() on any error.
+//
+// Example:
+//
+//	func BenchmarkImport(b *testing.B) {
+//	    db := postgres.BenchmarkDB(b,
+//	        testdb.WithMigrations("./migrations"),
+//	        testdb.WithMigrationTool(testdb.MigrationToolTern))
+//	    pool := db.Pool()
+//
+//	    for b.Loop() {
+//	        if err := Import(ctx, pool, records); err != nil {
+//	            b.Fatal(err)
+//	        }
+//	        db.ResetFromTemplate()
+//	    }
+//	}
+func BenchmarkDB(b *testing.B, opts ...testdb.Option) *BenchDB {
+	b.Helper()
+	const callerName = "postgres.BenchmarkDB"
+	ctx := context.Background()
+
+	template, err := testdb.New(b, &PostgresProvider{}, nil, opts...)
+	if err != nil {
+		b.Fatalf("%s: create template: %v", callerName, err)
+	}
+	registerCleanup(b, template)
+
+	if template.Config().MigrationDir != "" && !template.Config().MigrateBeforeInit {
+		if err := template.RunMigrations(); err != nil {
+			b.Fatalf("%s: migrations failed: %v", callerName, err)
+		}
+	}
+	applyTableStorageParamsIfConfigured(b, template, callerName)
+
+	cloneOpts := append(slices.Clone(opts), cloneFromTemplate(template.Name()))
+	db, err := testdb.New(b, &PostgresProvider{}, &PoolInitializer{}, cloneOpts...)
+	if err != nil {
+		b.Fatalf("%s: %v", callerName, err)
+	}
+	registerCleanup(b, db)
+
+	// A provider of its own drops and re-creates the database on reset; the
+	// one inside db drops it for good on cleanup
+	admin := &PostgresProvider{}
+	if err := admin.Initialize(ctx, db.Config()); err != nil {
+		b.Fatalf("%s: %v", callerName, err)
+	}
+	b.Cleanup(func() { _ = admin.Cleanup(context.Background()) })
+
+	b.ResetTimer()
+	return &BenchDB{
+		b:     b,
+		pool:  db.Entity().(*pgxpool.Pool),
+		name:  db.Name(),
+		admin: admin,
+	}
+}
+
+// cloneFromTemplate configures the benchmark database of BenchmarkDB to be
+// cloned from template, without applying again what the template already
+// contains. A testdb.WithCreateDatabaseSQL statement is dropped, since it
+// wouldn't clone the template; the clone inherits the template's encoding
+// and locale instead.
+func cloneFromTemplate(template string) testdb.Option {
+	return func(c *testdb.Config) {
+		c.Template = template
+		c.CreateDatabaseSQL = nil
+		c.Restore = testdb.RestoreSource{}
+		c.MigrateBeforeInit = false
+	}
+}
+
+// Pool returns the connection pool for the benchmark database. It stays
+// valid across ResetFromTemplate calls, so fetch it once before the loop.
+func (d *BenchDB) Pool() *pgxpool.Pool {
+	return d.pool
+}
+
+// Name returns the name of the benchmark database.
+func (d *BenchDB) Name() string {
+	return d.name
+}
+
+// ResetFromTemplate restores the benchmark database to its migrated state. It
+// closes the pool's connections, terminates any others, drops the database
+// and clones it again from the template under the same name, so the pool
+// reconnects to the fresh copy on its next query. The benchmark timer is
+// stopped while it runs.
+//
+// Call it at the end of each b.Loop() iteration, after any rows or
+// connections acquired from the pool in the iteration have been released:
+// connections still checked out are only closed once released, and keep the
+// old database from being dropped until then.
+//
+// Calls b.Fatal() on any error.
+func (d *BenchDB) ResetFromTemplate() {
+	d.b.Helper()
+	d.b.StopTimer()
+	defer d.b.StartTimer()
+
+	if err := d.reset(context.Background()); err != nil {
+		d.b.Fatalf("postgres.BenchDB.ResetFromTemplate: %v", err)
+	}
+}
+
+// reset drops the benchmark database and clones it from the template again.
+func (d *BenchDB) reset(ctx context.Context) error {
+	d.pool.Reset()
+
+	if err := d.admin.TerminateConnections(ctx, d.name); err != nil {
+		return err
+	}
+	if err := d.admin.dropDatabase(ctx, d.name); err != nil {
+		return fmt.Errorf("drop database: %w", err)
+	}
+	if err := d.admin.createDatabase(ctx, d.name); err != nil {
+		return err
+	}
+	if err := d.admin.grantDatabasePrivileges(ctx, d.name); err != nil {
+		return err
+	}
+	// Settings from WithDatabaseSettings aren't cloned with the template
+	return d.admin.applyDatabaseSettings(ctx, d.name)
+}
+
+// truncateTables truncates all tables in the search_path schemas except the
+// migration bookkeeping tables, including migrationTable (set with
+// testdb.WithMigrationTable, if any), in a single statement.
+func truncateTables(ctx context.Context, pool *pgxpool.Pool, migrationTable string) error {
+	quoted, err := UserTables(ctx, pool, migrationTable)
+	if err != nil {
+		return err
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+
+	sql := fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY CASCADE", strings.Join(quoted, ", "))
+	if _, err := pool.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("truncate tables: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestSetupBReset(t *testing.T) {
+	ctx := context.Background()
+
+	var failed bool
+	var leftover int
+	testing.Benchmark(func(b *testing.B) {
+		// Fatal in a benchmark exits the goroutine, so record failure in a defer
+		defer func() { failed = b.Failed() }()
+
+		pool, reset := postgres.SetupB(b)
+		if _, err := pool.Exec(ctx, `
+            CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT);
+            CREATE TABLE schema_migrations (version BIGINT);
+            INSERT INTO schema_migrations VALUES (1);
+        `); err != nil {
+			b.Fatalf("failed to create tables: %v", err)
+		}
+
+		for b.Loop() {
+			if _, err := pool.Exec(ctx, "INSERT INTO users (name) VALUES ('bench')"); err != nil {
+				b.Fatalf("insert failed: %v", err)
+			}
+			reset()
+		}
+
+		if err := pool.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&leftover); err != nil {
+			b.Fatalf("count failed: %v", err)
+		}
+
+		var migrations int
+		if err := pool.QueryRow(ctx, "SELECT count(*) FROM schema_migrations").Scan(&migrations); err != nil {
+			b.Fatalf("count failed: %v", err)
+		}
+		if migrations != 1 {
+			b.Errorf("expected reset to keep migration bookkeeping, got %d rows", migrations)
+		}
+	})
+
+	if failed {
+		t.Fatal("benchmark failed")
+	}
+	if leftover != 0 {
+		t.Errorf("expected reset to truncate users, got %d rows", leftover)
+	}
+}
+
+func BenchmarkSetupB(b *testing.B) {
+	ctx := context.Background()
+	pool, reset := postgres.SetupB(b)
+	if _, err := pool.Exec(ctx, "CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT)"); err != nil {
+		b.Fatalf("failed to create table: %v", err)
+	}
+
+	for b.Loop() {
+		if _, err := pool.Exec(ctx, "INSERT INTO users (name) VALUES ('bench')"); err != nil {
+			b.Fatalf("insert failed: %v", err)
+		}
+		reset()
+	}
+}
+
+func TestBenchmarkDBResetFromTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	var failed bool
+	var rows, tables int
+	var name, current string
+	testing.Benchmark(func(b *testing.B) {
+		defer func() { failed = b.Failed() }()
+
+		db := postgres.BenchmarkDB(b,
+			testdb.WithMigrations("../testdata/postgres/migrations_migrate"),
+			testdb.WithMigrationTool(testdb.MigrationToolMigrate),
+			testdb.WithMigrateInProcess())
+		pool := db.Pool()
+		name = db.Name()
+
+		for b.Loop() {
+			if _, err := pool.Exec(ctx, `
+                INSERT INTO test_table (name) VALUES ('bench');
+                CREATE TABLE scratch (id INT);
+            `); err != nil {
+				b.Fatalf("iteration failed: %v", err)
+			}
+			db.ResetFromTemplate()
+		}
+
+		if err := pool.QueryRow(ctx, "SELECT count(*) FROM test_table").Scan(&rows); err != nil {
+			b.Fatalf("count failed: %v", err)
+		}
+		if err := pool.QueryRow(ctx, "SELECT count(*) FROM pg_tables WHERE tablename = 'scratch'").Scan(&tables); err != nil {
+			b.Fatalf("count failed: %v", err)
+		}
+		if err := pool.QueryRow(ctx, "SELECT current_database()").Scan(&current); err != nil {
+			b.Fatalf("query failed: %v", err)
+		}
+	})
+
+	if failed {
+		t.Fatal("benchmark failed")
+	}
+	if rows != 0 {
+		t.Errorf("expected the reset to remove inserted rows, got %d", rows)
+	}
+	if tables != 0 {
+		t.Error("expected the reset to remove tables created by the benchmark")
+	}
+	if current != name {
+		t.Errorf("expected the pool to reconnect to %s, got %s", name, current)
+	}
+}
+
+func TestBenchmarkDBResetKeepsDatabaseSettings(t *testing.T) {
+	ctx := context.Background()
+
+	var failed bool
+	var timeZone string
+	testing.Benchmark(func(b *testing.B) {
+		defer func() { failed = b.Failed() }()
+
+		db := postgres.BenchmarkDB(b,
+			postgres.WithDatabaseSettings(map[string]string{"TimeZone": "America/Chicago"}))
+		db.ResetFromTemplate()
+
+		if err := db.Pool().QueryRow(ctx, "SELECT current_setting('TimeZone')").Scan(&timeZone); err != nil {
+			b.Fatalf("query failed: %v", err)
+		}
+	})
+
+	if failed {
+		t.Fatal("benchmark failed")
+	}
+	if timeZone != "America/Chicago" {
+		t.Errorf("expected TimeZone America/Chicago after the reset, got %q", timeZone)
+	}
+}
+
+func BenchmarkResetFromTemplate(b *testing.B) {
+	ctx := context.Background()
+	db := postgres.BenchmarkDB(b,
+		testdb.WithMigrations("../testdata/postgres/migrations_migrate"),
+		testdb.WithMigrationTool(testdb.MigrationToolMigrate),
+		testdb.WithMigrateInProcess())
+	pool := db.Pool()
+
+	for b.Loop() {
+		if _, err := pool.Exec(ctx, "INSERT INTO test_table (name) VALUES ('bench')"); err != nil {
+			b.Fatalf("insert failed: %v", err)
+		}
+		db.ResetFromTemplate()
+	}
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+// ConnInitializer creates a single, non-pooled *pgx.Conn for the test
+// database, for tests that depend on session state staying on one connection:
+// LISTEN/NOTIFY, temporary tables, session-level advisory locks or SET
+// without LOCAL. A pool may hand each query a different connection; a Conn
+// never does.
+//
+// The entity is a *Conn, whose Close satisfies io.Closer so the connection is
+// closed by the cleanup registered by New.
+//
+// Example:
+//
+//	db := postgres.New(t, &postgres.ConnInitializer{})
+//	conn := db.Entity().(*postgres.Conn)
+//
+//	_, err := conn.Exec(ctx, "LISTEN jobs")
+//	notification, err := conn.WaitForNotification(ctx)
+type ConnInitializer struct {
+	// ConfigModifier allows customization of the connection configuration
+	// after the DSN is parsed but before connecting.
+	ConfigModifier func(*pgx.ConnConfig)
+}
+
+// Conn is the entity created by ConnInitializer: a *pgx.Conn whose Close
+// takes no context, so it implements io.Closer. Use the embedded Conn for
+// everything else, or pass it where a *pgx.Conn is expected.
+type Conn struct {
+	*pgx.Conn
+}
+
+// Close closes the connection with a background context.
+func (c *Conn) Close() error {
+	return c.Conn.Close(context.Background())
+}
+
+// InitializeTestDatabase connects to the test database with pgx.ConnectConfig.
+//
+// Options that apply per connection are honored: testdb.WithTLSConfig,
+// testdb.WithConnectTimeout, WithSessionParams and WithNoticeHandler configure
+// the connection, and testdb.WithConnInitSQL statements run once it is open.
+// The connection is verified with a ping, or the query set with
+// WithValidationQuery. On error, the connection is closed.
+func (ci *ConnInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse DSN: %w", err)
+	}
+
+	cfg, hasCfg := testdb.ConfigFromContext(ctx)
+	if hasCfg {
+		// The test DSN's connect_timeout is in whole seconds; use the exact value
+		if cfg.ConnectTimeout > 0 {
+			config.ConnectTimeout = cfg.ConnectTimeout
+		}
+		applySessionParams(config, cfg.SessionParams)
+		if cfg.NoticeHandler != nil {
+			config.OnNotice = noticeHandler(cfg.NoticeHandler)
+		}
+	}
+
+	if ci.ConfigModifier != nil {
+		ci.ConfigModifier(config)
+	}
+
+	if hasCfg {
+		applyTLSConfig(config, cfg.TLSConfig)
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	if hasCfg && len(cfg.ConnInitSQL) > 0 {
+		if err := connInitSQLHook(cfg.ConnInitSQL)(ctx, conn); err != nil {
+			_ = conn.Close(context.WithoutCancel(ctx)) // Best effort cleanup
+			return nil, err
+		}
+	}
+
+	// Verify connection
+	if hasCfg && cfg.ValidationQuery != "" {
+		if _, err := conn.Exec(ctx, cfg.ValidationQuery); err != nil {
+			_ = conn.Close(context.WithoutCancel(ctx)) // Best effort cleanup
+			return nil, fmt.Errorf("validate connection: %w", err)
+		}
+	} else if err := conn.Ping(ctx); err != nil {
+		_ = conn.Close(context.WithoutCancel(ctx)) // Best effort cleanup
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+
+	return &Conn{Conn: conn}, nil
+}
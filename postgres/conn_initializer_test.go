@@ -0,0 +1,92 @@
+package postgres_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestConnInitializer(t *testing.T) {
+	ctx := context.Background()
+	db := postgres.New(t, &postgres.ConnInitializer{},
+		testdb.WithConnInitSQL("SET TIME ZONE 'America/Chicago'"),
+		postgres.WithSessionParams(map[string]string{"application_name": "conn_initializer"}))
+
+	conn, ok := db.Entity().(*postgres.Conn)
+	if !ok {
+		t.Fatalf("expected *postgres.Conn, got %T", db.Entity())
+	}
+	if _, ok := db.Entity().(io.Closer); !ok {
+		t.Fatal("expected the entity to implement io.Closer")
+	}
+
+	var tz, appName string
+	if err := conn.QueryRow(ctx, "SELECT current_setting('TimeZone'), current_setting('application_name')").Scan(&tz, &appName); err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+	if tz != "America/Chicago" {
+		t.Errorf("expected WithConnInitSQL to set TimeZone, got %q", tz)
+	}
+	if appName != "conn_initializer" {
+		t.Errorf("expected WithSessionParams to set application_name, got %q", appName)
+	}
+}
+
+func TestConnInitializerListenNotify(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := postgres.New(t, &postgres.ConnInitializer{})
+	conn := db.Entity().(*postgres.Conn)
+
+	if _, err := conn.Exec(ctx, "LISTEN jobs"); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if _, err := conn.Exec(ctx, "SELECT pg_notify('jobs', 'hello')"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+
+	notification, err := conn.WaitForNotification(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for notification: %v", err)
+	}
+	if notification.Channel != "jobs" || notification.Payload != "hello" {
+		t.Errorf("expected notification on jobs with payload hello, got %s: %s", notification.Channel, notification.Payload)
+	}
+}
+
+func TestConnInitializerConfigModifier(t *testing.T) {
+	var modified bool
+	db := postgres.New(t, &postgres.ConnInitializer{
+		ConfigModifier: func(config *pgx.ConnConfig) {
+			modified = true
+			config.RuntimeParams["search_path"] = "pg_catalog"
+		},
+	})
+
+	if !modified {
+		t.Fatal("expected ConfigModifier to be called")
+	}
+
+	var searchPath string
+	conn := db.Entity().(*postgres.Conn)
+	if err := conn.QueryRow(context.Background(), "SHOW search_path").Scan(&searchPath); err != nil {
+		t.Fatalf("failed to read search_path: %v", err)
+	}
+	if searchPath != "pg_catalog" {
+		t.Errorf("expected search_path pg_catalog, got %q", searchPath)
+	}
+}
+
+func TestConnInitializerConnectError(t *testing.T) {
+	initializer := &postgres.ConnInitializer{}
+	_, err := initializer.InitializeTestDatabase(context.Background(), "postgres://nobody@127.0.0.1:1/none?connect_timeout=1")
+	if err == nil {
+		t.Fatal("expected an error connecting to a closed port")
+	}
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bashhack/testdb"
+)
+
+// createDatabaseOptions is the allowlist of CREATE DATABASE options accepted by
+// testdb.WithCreateDatabaseOptions. TEMPLATE and OWNER are left out: they have
+// their own options (testdb.WithTemplate, testdb.WithDBOwner).
+var createDatabaseOptions = map[string]bool{
+	"ALLOW_CONNECTIONS": true,
+	"BUILTIN_LOCALE":    true,
+	"COLLATION_VERSION": true,
+	"CONNECTION LIMIT":  true,
+	"ENCODING":          true,
+	"ICU_LOCALE":        true,
+	"ICU_RULES":         true,
+	"IS_TEMPLATE":       true,
+	"LC_COLLATE":        true,
+	"LC_CTYPE":          true,
+	"LOCALE":            true,
+	"LOCALE_PROVIDER":   true,
+	"STRATEGY":          true,
+	"TABLESPACE":        true,
+}
+
+var (
+	optionKeywordPattern = regexp.MustCompile(`^[A-Za-z_]+`)
+
+	// Unquoted values: integers (CONNECTION LIMIT -1), booleans and plain
+	// identifiers (TABLESPACE fast_disk, STRATEGY file_copy)
+	optionValuePattern = regexp.MustCompile(`^(-?[0-9]+|[A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// parseCreateDatabaseOptions validates the clause set with
+// testdb.WithCreateDatabaseOptions and returns it rebuilt from its parsed
+// tokens, so nothing but allowlisted options and their values can reach the
+// CREATE DATABASE statement.
+//
+// The clause is a sequence of "OPTION [=] value" pairs, where OPTION is in
+// createDatabaseOptions and value is an integer, an identifier or a single-quoted
+// string literal (quotes inside are doubled; backslashes are rejected). Each
+// option may appear once.
+func parseCreateDatabaseOptions(clause string) (string, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", testdb.ErrInvalidCreateDatabaseOptions, fmt.Sprintf(format, args...))
+	}
+
+	var parts []string
+	seen := make(map[string]bool)
+	rest := strings.TrimSpace(clause)
+	for rest != "" {
+		keyword := optionKeywordPattern.FindString(rest)
+		if keyword == "" {
+			return "", invalid("expected an option at %q", rest)
+		}
+		keyword = strings.ToUpper(keyword)
+		rest = strings.TrimSpace(rest[len(keyword):])
+
+		if keyword == "CONNECTION" {
+			limit := optionKeywordPattern.FindString(rest)
+			if !strings.EqualFold(limit, "LIMIT") {
+				return "", invalid("expected LIMIT after CONNECTION")
+			}
+			keyword = "CONNECTION LIMIT"
+			rest = strings.TrimSpace(rest[len(limit):])
+		}
+
+		if !createDatabaseOptions[keyword] {
+			return "", invalid("option %s is not allowed", keyword)
+		}
+		if seen[keyword] {
+			return "", invalid("option %s is given more than once", keyword)
+		}
+		seen[keyword] = true
+
+		if strings.HasPrefix(rest, "=") {
+			rest = strings.TrimSpace(rest[1:])
+		}
+
+		value, n, err := optionValue(rest)
+		if err != nil {
+			return "", invalid("%s: %v", keyword, err)
+		}
+		rest = strings.TrimSpace(rest[n:])
+
+		parts = append(parts, keyword+" "+value)
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// optionValue scans the value at the start of s, returning it and the number
+// of bytes it spans.
+func optionValue(s string) (string, int, error) {
+	if !strings.HasPrefix(s, "'") {
+		value := optionValuePattern.FindString(s)
+		if value == "" {
+			return "", 0, fmt.Errorf("expected a value at %q", s)
+		}
+		// A value running straight into other characters, as in 10;DROP, is
+		// not a value
+		if len(s) > len(value) && !strings.ContainsRune(" \t\n\r", rune(s[len(value)])) {
+			return "", 0, fmt.Errorf("unexpected %q after %s", s[len(value):], value)
+		}
+		return value, len(value), nil
+	}
+
+	for i := 1; i < len(s); i++ {
+		// Backslashes escape quotes when standard_conforming_strings is off
+		if s[i] == '\\' {
+			return "", 0, fmt.Errorf("backslash in string literal %s", s)
+		}
+		if s[i] != '\'' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			i++ // Escaped quote
+			continue
+		}
+		if i+1 < len(s) && !strings.ContainsRune(" \t\n\r", rune(s[i+1])) {
+			return "", 0, fmt.Errorf("unexpected %q after string literal", s[i+1:])
+		}
+		return s[:i+1], i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated string literal %s", s)
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// credentialFunc fetches admin credentials (testdb.WithCredentialProvider).
+type credentialFunc func(ctx context.Context) (user, password string, err error)
+
+// applyCredentials sets the user and password of config to those returned by
+// credentials.
+func applyCredentials(ctx context.Context, config *pgx.ConnConfig, credentials credentialFunc) error {
+	user, password, err := credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch admin credentials: %w", err)
+	}
+	config.User = user
+	config.Password = password
+	return nil
+}
+
+// refreshAdminConn reconnects the dedicated admin connection with freshly
+// fetched credentials if it was lost since Initialize, so cleanup still works
+// after the setup credentials expired (testdb.WithCredentialProvider).
+func (p *PostgresProvider) refreshAdminConn(ctx context.Context) error {
+	if p.credentials == nil || p.conn == nil {
+		return nil
+	}
+	if err := p.conn.Ping(ctx); err == nil {
+		return nil
+	}
+
+	config := p.adminConfig.Copy()
+	if err := applyCredentials(ctx, config, p.credentials); err != nil {
+		return err
+	}
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("reconnect to admin database: %w", err)
+	}
+
+	_ = p.conn.Close(ctx) // Already broken
+	p.conn = conn
+	p.admin = conn
+	return nil
+}
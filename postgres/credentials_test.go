@@ -0,0 +1,56 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestWithCredentialProvider(t *testing.T) {
+	u, err := url.Parse(testAdminDSN())
+	if err != nil {
+		t.Fatalf("failed to parse admin DSN: %v", err)
+	}
+	user := u.User.Username()
+	password, _ := u.User.Password()
+
+	// The DSN's own credentials are wrong; only the provider's work
+	u.User = url.UserPassword("nobody", "wrong")
+
+	var calls atomic.Int32
+	pool := postgres.Setup(t,
+		testdb.WithAdminDSN(u.String()),
+		testdb.WithCredentialProvider(func(ctx context.Context) (string, string, error) {
+			calls.Add(1)
+			return user, password, nil
+		}))
+
+	var current string
+	if err := pool.QueryRow(context.Background(), "SELECT current_user").Scan(&current); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if current != user {
+		t.Errorf("expected the test DSN to use the provider's user %s, got %s", user, current)
+	}
+	if calls.Load() == 0 {
+		t.Error("expected the credential provider to be called")
+	}
+}
+
+func TestWithCredentialProviderError(t *testing.T) {
+	errVault := errors.New("vault sealed")
+
+	_, err := testdb.New(t, &postgres.PostgresProvider{}, nil,
+		testdb.WithAdminDSN("postgres://localhost:1/postgres"),
+		testdb.WithCredentialProvider(func(ctx context.Context) (string, string, error) {
+			return "", "", errVault
+		}))
+	if !errors.Is(err, errVault) {
+		t.Errorf("expected the credential provider's error, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidDatabaseSetting is returned when WithDatabaseSettings is given an
+// invalid parameter name.
+var ErrInvalidDatabaseSetting = errors.New("invalid database setting")
+
+// databaseSettingPattern matches a configuration parameter name, optionally
+// with an extension prefix (e.g. "pg_trgm.similarity_threshold").
+var databaseSettingPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// WithDatabaseSettings sets configuration parameters on each test database
+// after it is created (ALTER DATABASE ... SET name = value). Unlike
+// WithSessionParams, which only reach the test entity's connections, they
+// apply to every connection to the database, including the migration tool's
+// and any the code under test opens itself.
+//
+// Values are sent as string literals. A value containing commas is set as a
+// list, each element quoted separately, as search_path and DateStyle expect.
+// Parameter names are validated when the provider is initialized; an invalid
+// name fails setup with ErrInvalidDatabaseSetting, and a parameter PostgreSQL
+// rejects fails creating the database. Calling WithDatabaseSettings more than
+// once merges the maps. Settings are skipped under schema isolation, where the
+// test has no database of its own.
+//
+// Example:
+//
+//	pool := postgres.Setup(t, postgres.WithDatabaseSettings(map[string]string{
+//	    "search_path": "app, public",
+//	    "TimeZone":    "UTC",
+//	}))
+func WithDatabaseSettings(settings map[string]string) testdb.Option {
+	return func(c *testdb.Config) {
+		merged := maps.Clone(c.DatabaseSettings)
+		if merged == nil {
+			merged = make(map[string]string, len(settings))
+		}
+		maps.Copy(merged, settings)
+		c.DatabaseSettings = merged
+	}
+}
+
+// databaseSettingClauses returns the "name = value" clauses for settings, in
+// name order, after validating the names.
+func databaseSettingClauses(settings map[string]string) ([]string, error) {
+	var clauses []string
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		if !databaseSettingPattern.MatchString(name) {
+			return nil, fmt.Errorf("%w: parameter name %q", ErrInvalidDatabaseSetting, name)
+		}
+
+		var values []string
+		for _, value := range strings.Split(settings[name], ",") {
+			values = append(values, "'"+strings.ReplaceAll(strings.TrimSpace(value), "'", "''")+"'")
+		}
+		clauses = append(clauses, fmt.Sprintf("%s = %s", name, strings.Join(values, ", ")))
+	}
+	return clauses, nil
+}
+
+// applyDatabaseSettings sets the parameters configured with
+// WithDatabaseSettings on the named test database. It is a no-op under schema
+// isolation.
+func (p *PostgresProvider) applyDatabaseSettings(ctx context.Context, name string) error {
+	if _, ok := p.schemas[name]; ok {
+		return nil
+	}
+
+	quotedName := pgx.Identifier{name}.Sanitize()
+	for _, clause := range p.dbSettings {
+		if _, err := p.admin.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s SET %s", quotedName, clause)); err != nil {
+			return fmt.Errorf("set database parameter: %w", err)
+		}
+	}
+	return nil
+}
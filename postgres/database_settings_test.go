@@ -0,0 +1,48 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestWithDatabaseSettings(t *testing.T) {
+	ctx := context.Background()
+	db := postgres.New(t, &postgres.PoolInitializer{},
+		postgres.WithDatabaseSettings(map[string]string{
+			"search_path": "app, public",
+			"TimeZone":    "America/Chicago",
+		}))
+
+	// A connection of its own, outside the test entity's pool, gets them too
+	conn, err := pgx.Connect(ctx, db.DSN())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() { _ = conn.Close(ctx) }()
+
+	var searchPath, timeZone string
+	if err := conn.QueryRow(ctx, "SELECT current_setting('search_path'), current_setting('TimeZone')").Scan(&searchPath, &timeZone); err != nil {
+		t.Fatalf("failed to query settings: %v", err)
+	}
+	if searchPath != "app, public" {
+		t.Errorf("expected search_path 'app, public', got %q", searchPath)
+	}
+	if timeZone != "America/Chicago" {
+		t.Errorf("expected TimeZone America/Chicago, got %q", timeZone)
+	}
+}
+
+func TestWithDatabaseSettingsInvalidName(t *testing.T) {
+	_, err := testdb.New(t, &postgres.PostgresProvider{}, nil,
+		postgres.WithDatabaseSettings(map[string]string{
+			"search_path = public; DROP DATABASE postgres; --": "app",
+		}))
+	if !errors.Is(err, postgres.ErrInvalidDatabaseSetting) {
+		t.Errorf("expected ErrInvalidDatabaseSetting, got %v", err)
+	}
+}
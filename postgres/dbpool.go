@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBPool hands out migrated test databases for reuse across tests, so the
+// cost of CREATE DATABASE and migrations is paid once per database instead of
+// once per test. Create it with NewPool.
+//
+// It is safe for concurrent use by parallel tests.
+type DBPool struct {
+	owner testing.TB
+	opts  []testdb.Option
+
+	mu     sync.Mutex
+	idle   []*pooledDB
+	all    []*pooledDB
+	closed bool
+}
+
+// pooledDB is a test database owned by a DBPool, with its connection pool.
+type pooledDB struct {
+	db   *testdb.TestDatabase
+	pool *pgxpool.Pool
+}
+
+// NewPool creates a DBPool whose databases are created with opts, like Setup:
+// each database is migrated once, when it is created. Databases are created on
+// demand, when a test calls Acquire and none is free, so the pool grows to the
+// number of tests holding a database at the same time.
+//
+// All databases are dropped when t finishes, via t.Cleanup(). Create the pool
+// in a parent test (or a package-level helper called from one) whose subtests
+// acquire from it.
+//
+// Example:
+//
+//	func TestUsers(t *testing.T) {
+//	    dbs := postgres.NewPool(t,
+//	        testdb.WithMigrations("./migrations"),
+//	        testdb.WithMigrationTool(testdb.MigrationToolTern))
+//
+//	    for _, tc := range cases {
+//	        t.Run(tc.name, func(t *testing.T) {
+//	            t.Parallel()
+//	            pool := dbs.Acquire(t)
+//	            // ...
+//	        })
+//	    }
+//	}
+func NewPool(t testing.TB, opts ...testdb.Option) *DBPool {
+	t.Helper()
+
+	p := &DBPool{owner: t, opts: opts}
+	t.Cleanup(p.close)
+	return p
+}
+
+// Acquire checks out a migrated database for the calling test and returns a
+// connection pool for it. A free database is reused if there is one;
+// otherwise a new one is created and migrated.
+//
+// When t finishes, every table except the migration tools' bookkeeping tables
+// is truncated (restarting identity sequences) and the database is returned
+// to the pool. Only data is reset: schema changes made by the test, such as
+// created tables or altered columns, carry over to the next test using the
+// database, so tests that change the schema should use Setup instead. A
+// database that can't be truncated is dropped rather than reused.
+//
+// Calls t.Fatal() on any error.
+func (p *DBPool) Acquire(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		t.Fatalf("postgres.DBPool.Acquire: pool is closed")
+	}
+	var pdb *pooledDB
+	if n := len(p.idle); n > 0 {
+		pdb = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+	}
+	p.mu.Unlock()
+
+	if pdb == nil {
+		pdb = p.create(t)
+	}
+
+	t.Cleanup(func() { p.release(t, pdb) })
+	return pdb.pool
+}
+
+// create creates and migrates a new database for the pool. The database is
+// owned by the pool, so it outlives the test that triggered its creation.
+func (p *DBPool) create(t testing.TB) *pooledDB {
+	t.Helper()
+
+	db, err := testdb.New(p.owner, &PostgresProvider{}, &PoolInitializer{}, p.opts...)
+	if err != nil {
+		t.Fatalf("postgres.DBPool.Acquire: %v", err)
+	}
+	pdb := &pooledDB{db: db, pool: db.Entity().(*pgxpool.Pool)}
+
+	if db.Config().MigrationDir != "" && !db.Config().MigrateBeforeInit {
+		if err := db.RunMigrations(); err != nil {
+			p.drop(t, pdb)
+			t.Fatalf("postgres.DBPool.Acquire: migrations failed: %v", err)
+		}
+	}
+
+	if err := applyTableStorageParams(context.Background(), db); err != nil {
+		p.drop(t, pdb)
+		t.Fatalf("postgres.DBPool.Acquire: %v", err)
+	}
+
+	p.mu.Lock()
+	p.all = append(p.all, pdb)
+	p.mu.Unlock()
+	return pdb
+}
+
+// release truncates a database checked out by t and returns it to the pool.
+func (p *DBPool) release(t testing.TB, pdb *pooledDB) {
+	if err := truncateTables(context.Background(), pdb.pool, pdb.db.Config().MigrationTable); err != nil {
+		t.Logf("postgres.DBPool: dropping database %s instead of reusing it: %v", pdb.db.Name(), err)
+		p.mu.Lock()
+		p.remove(pdb)
+		p.mu.Unlock()
+		p.drop(t, pdb)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.idle = append(p.idle, pdb)
+	}
+}
+
+// remove removes pdb from the pool's databases. p.mu must be held.
+func (p *DBPool) remove(pdb *pooledDB) {
+	for i, other := range p.all {
+		if other == pdb {
+			p.all = append(p.all[:i], p.all[i+1:]...)
+			return
+		}
+	}
+}
+
+// drop closes a database's connection pool and drops the database.
+func (p *DBPool) drop(t testing.TB, pdb *pooledDB) {
+	pdb.pool.Close()
+	if err := pdb.db.Close(); err != nil {
+		t.Errorf("testdb cleanup failed: %v", err)
+	}
+}
+
+// close drops every database in the pool. It runs when the owning test
+// finishes, after the tests that acquired databases have released them.
+func (p *DBPool) close() {
+	p.mu.Lock()
+	all := p.all
+	p.all, p.idle, p.closed = nil, nil, true
+	p.mu.Unlock()
+
+	for _, pdb := range all {
+		p.drop(p.owner, pdb)
+	}
+}
@@ -0,0 +1,61 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestDBPool(t *testing.T) {
+	ctx := context.Background()
+	dbs := postgres.NewPool(t,
+		testdb.WithMigrations("../testdata/postgres/migrations_migrate"),
+		testdb.WithMigrationTool(testdb.MigrationToolMigrate),
+		testdb.WithMigrateInProcess())
+
+	var firstDB string
+	t.Run("first test writes data", func(t *testing.T) {
+		pool := dbs.Acquire(t)
+		firstDB = currentDatabase(t, pool)
+
+		if _, err := pool.Exec(ctx, "INSERT INTO test_table (name) VALUES ('first')"); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+	})
+
+	t.Run("second test reuses the truncated database", func(t *testing.T) {
+		pool := dbs.Acquire(t)
+		if got := currentDatabase(t, pool); got != firstDB {
+			t.Errorf("expected database %s to be reused, got %s", firstDB, got)
+		}
+
+		var count int
+		if err := pool.QueryRow(ctx, "SELECT count(*) FROM test_table").Scan(&count); err != nil {
+			t.Fatalf("count failed: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected test_table to be truncated, got %d rows", count)
+		}
+	})
+
+	t.Run("databases in use are not shared", func(t *testing.T) {
+		a := currentDatabase(t, dbs.Acquire(t))
+		b := currentDatabase(t, dbs.Acquire(t))
+		if a == b {
+			t.Errorf("expected a second database while %s is checked out", a)
+		}
+	})
+}
+
+// currentDatabase returns the name of the database pool is connected to.
+func currentDatabase(t *testing.T, pool *pgxpool.Pool) string {
+	t.Helper()
+	var name string
+	if err := pool.QueryRow(context.Background(), "SELECT current_database()").Scan(&name); err != nil {
+		t.Fatalf("failed to query current database: %v", err)
+	}
+	return name
+}
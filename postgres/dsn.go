@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// connectionTargetParams are the DSN parameters BuildDSN sets itself from the
+// parsed admin config, so they are not carried over from the admin DSN.
+var connectionTargetParams = []string{"host", "port", "user", "password", "dbname", "database"}
+
+// adminDSNParams returns the connection parameters of the admin DSN (URL or
+// keyword/value format) that BuildDSN carries over to test DSNs, such as
+// connect_timeout, application_name, options and TLS settings. The connection
+// target (host, port, user, password, database) and pgxpool's pool_*
+// settings, which configure the admin pool rather than connections, are left
+// out.
+//
+// Each parameter has a single value, the one pgx used for the admin
+// connection: a repeated URL query parameter ("?sslmode=disable&sslmode=require")
+// keeps its first value, and a repeated keyword/value parameter its last.
+// Drivers disagree on which duplicate wins, so test DSNs never repeat one.
+func adminDSNParams(dsn string) (url.Values, error) {
+	var params url.Values
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		params = u.Query()
+		for name, values := range params {
+			params[name] = values[:1]
+		}
+	} else {
+		params, err = keywordValueParams(dsn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range connectionTargetParams {
+		params.Del(name)
+	}
+	for name := range params {
+		if strings.HasPrefix(name, "pool_") {
+			params.Del(name)
+		}
+	}
+	return params, nil
+}
+
+// keywordValueParams parses a keyword/value DSN ("host=localhost
+// dbname=postgres connect_timeout=10"). Values may be single-quoted, and a
+// backslash escapes the next character.
+func keywordValueParams(dsn string) (url.Values, error) {
+	params := url.Values{}
+
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid DSN: missing \"=\" after %q", s)
+		}
+		key = strings.TrimSpace(key)
+		rest = strings.TrimLeft(rest, " \t\n\r")
+
+		var value strings.Builder
+		quoted := strings.HasPrefix(rest, "'")
+		if quoted {
+			rest = rest[1:]
+		}
+
+		i := 0
+		for ; i < len(rest); i++ {
+			c := rest[i]
+			if c == '\\' && i+1 < len(rest) {
+				i++
+				value.WriteByte(rest[i])
+				continue
+			}
+			if (quoted && c == '\'') || (!quoted && strings.IndexByte(" \t\n\r", c) >= 0) {
+				break
+			}
+			value.WriteByte(c)
+		}
+		if quoted {
+			if i == len(rest) {
+				return nil, fmt.Errorf("invalid DSN: unterminated quoted value for %q", key)
+			}
+			i++ // Skip the closing quote
+		}
+
+		params.Set(key, value.String())
+		s = strings.TrimSpace(rest[i:])
+	}
+
+	return params, nil
+}
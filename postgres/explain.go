@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Plan is the executed query plan of a statement, as reported by
+// EXPLAIN (ANALYZE, FORMAT JSON).
+type Plan struct {
+	// Root is the top node of the plan tree.
+	Root PlanNode
+
+	// PlanningTime is the time the planner took to produce the plan.
+	PlanningTime time.Duration
+
+	// ExecutionTime is the time the statement took to run, excluding planning.
+	ExecutionTime time.Duration
+}
+
+// PlanNode is a node of a query plan. Fields not used by a node type are
+// left empty; for example, only scan nodes have a RelationName.
+type PlanNode struct {
+	// NodeType is the kind of operation, such as "Seq Scan", "Index Scan",
+	// "Index Only Scan", "Hash Join" or "Sort".
+	NodeType string `json:"Node Type"`
+
+	// RelationName is the table a scan node reads.
+	RelationName string `json:"Relation Name"`
+
+	// IndexName is the index an index scan node reads.
+	IndexName string `json:"Index Name"`
+
+	// PlanRows is the planner's estimate of the rows the node returns.
+	PlanRows float64 `json:"Plan Rows"`
+
+	// ActualRows is the average number of rows the node returned per loop.
+	ActualRows float64 `json:"Actual Rows"`
+
+	// ActualLoops is the number of times the node was executed.
+	ActualLoops float64 `json:"Actual Loops"`
+
+	// ActualTotalTime is the average time per loop, in milliseconds, until
+	// the node returned its last row, including its children.
+	ActualTotalTime float64 `json:"Actual Total Time"`
+
+	// Plans are the node's children.
+	Plans []PlanNode `json:"Plans"`
+}
+
+// Rows returns the number of rows the statement returned. For INSERT, UPDATE
+// and DELETE without RETURNING that is 0, since the root ModifyTable node
+// returns no rows; the rows it was fed are those of its child in Root.Plans.
+func (p Plan) Rows() int64 {
+	return int64(p.Root.ActualRows * p.Root.ActualLoops)
+}
+
+// TotalTime returns the planning and execution time of the statement.
+func (p Plan) TotalTime() time.Duration {
+	return p.PlanningTime + p.ExecutionTime
+}
+
+// Nodes returns every node of the plan tree, parents before their children.
+func (p Plan) Nodes() []PlanNode {
+	var nodes []PlanNode
+	var walk func(n PlanNode)
+	walk = func(n PlanNode) {
+		nodes = append(nodes, n)
+		for _, child := range n.Plans {
+			walk(child)
+		}
+	}
+	walk(p.Root)
+	return nodes
+}
+
+// NodeTypes returns the node type of every node of the plan tree, parents
+// before their children.
+func (p Plan) NodeTypes() []string {
+	var types []string
+	for _, n := range p.Nodes() {
+		types = append(types, n.NodeType)
+	}
+	return types
+}
+
+// HasNode reports whether the plan has a node of the given type, optionally
+// reading the given table: HasNode("Seq Scan", "users") reports whether users
+// is read with a sequential scan, and HasNode("Seq Scan", "") whether any
+// table is.
+func (p Plan) HasNode(nodeType, relation string) bool {
+	for _, n := range p.Nodes() {
+		if n.NodeType == nodeType && (relation == "" || n.RelationName == relation) {
+			return true
+		}
+	}
+	return false
+}
+
+// explainOutput is the JSON document produced by EXPLAIN (FORMAT JSON), an
+// array holding one entry per statement.
+type explainOutput []struct {
+	Plan          PlanNode `json:"Plan"`
+	PlanningTime  float64  `json:"Planning Time"`
+	ExecutionTime float64  `json:"Execution Time"`
+}
+
+// ExplainAnalyze runs sql with EXPLAIN (ANALYZE, FORMAT JSON) and returns its
+// executed plan, so performance tests can assert on how a query runs: which
+// nodes were used, how many rows came back, and how long it took.
+//
+// The statement is executed, so an INSERT, UPDATE or DELETE takes effect. The
+// planner's choices depend on table statistics, so run ANALYZE after loading
+// data (see WithAnalyzeAfterSeed) for plans that match production.
+//
+// Example:
+//
+//	plan, err := postgres.ExplainAnalyze(ctx, pool,
+//	    "SELECT * FROM users WHERE email = $1", "alice@example.com")
+//	require.NoError(t, err)
+//	assert.True(t, plan.HasNode("Index Scan", "users"))
+//	assert.Less(t, plan.ExecutionTime, 10*time.Millisecond)
+func ExplainAnalyze(ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) (Plan, error) {
+	var raw []byte
+	if err := pool.QueryRow(ctx, "EXPLAIN (ANALYZE, FORMAT JSON) "+sql, args...).Scan(&raw); err != nil {
+		return Plan{}, fmt.Errorf("explain analyze: %w", err)
+	}
+	return parsePlan(raw)
+}
+
+// parsePlan parses the output of EXPLAIN (ANALYZE, FORMAT JSON).
+func parsePlan(raw []byte) (Plan, error) {
+	var out explainOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return Plan{}, fmt.Errorf("parse plan: %w", err)
+	}
+	if len(out) != 1 {
+		return Plan{}, fmt.Errorf("parse plan: expected 1 plan, got %d", len(out))
+	}
+
+	return Plan{
+		Root:          out[0].Plan,
+		PlanningTime:  millis(out[0].PlanningTime),
+		ExecutionTime: millis(out[0].ExecutionTime),
+	}, nil
+}
+
+// millis converts a duration in (fractional) milliseconds, as EXPLAIN reports
+// times, to a time.Duration.
+func millis(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,66 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestExplainAnalyze(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	if _, err := pool.Exec(ctx, "CREATE TABLE users (id INT PRIMARY KEY, email TEXT NOT NULL)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	err := postgres.Seed(ctx, pool, `
+		INSERT INTO users (id, email)
+		SELECT i, 'user' || i || '@example.com' FROM generate_series(1, 10000) AS i;
+	`, postgres.WithAnalyzeAfterSeed())
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	tests := map[string]struct {
+		sql      string
+		args     []any
+		wantNode string
+		wantRows int64
+	}{
+		"primary key lookup uses the index": {
+			sql:      "SELECT * FROM users WHERE id = $1",
+			args:     []any{42},
+			wantNode: "Index Scan",
+			wantRows: 1,
+		},
+		"unindexed filter scans the table": {
+			sql:      "SELECT * FROM users WHERE email LIKE $1",
+			args:     []any{"user1%"},
+			wantNode: "Seq Scan",
+			wantRows: 1112,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			plan, err := postgres.ExplainAnalyze(ctx, pool, tc.sql, tc.args...)
+			if err != nil {
+				t.Fatalf("ExplainAnalyze failed: %v", err)
+			}
+			if !plan.HasNode(tc.wantNode, "users") {
+				t.Errorf("expected a %s on users, got nodes %v", tc.wantNode, plan.NodeTypes())
+			}
+			if got := plan.Rows(); got != tc.wantRows {
+				t.Errorf("expected %d rows, got %d", tc.wantRows, got)
+			}
+			if plan.ExecutionTime <= 0 {
+				t.Errorf("expected a positive execution time, got %s", plan.ExecutionTime)
+			}
+		})
+	}
+
+	if _, err := postgres.ExplainAnalyze(ctx, pool, "SELECT * FROM missing_table"); err == nil {
+		t.Error("expected an error for an invalid statement")
+	}
+}
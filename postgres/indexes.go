@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrTableNotFound is returned by ListIndexes and IndexExists when the table
+// doesn't exist.
+var ErrTableNotFound = errors.New("table not found")
+
+// IndexInfo describes an index of a table.
+type IndexInfo struct {
+	// Schema is the schema the index is in.
+	Schema string
+
+	// Name is the index name.
+	Name string
+
+	// Definition is the CREATE INDEX statement that would recreate the
+	// index, e.g. "CREATE UNIQUE INDEX users_email_key ON public.users USING
+	// btree (email)".
+	Definition string
+
+	// Unique reports whether the index enforces uniqueness.
+	Unique bool
+
+	// Primary reports whether the index backs the table's primary key.
+	Primary bool
+
+	// Predicate is the WHERE clause of a partial index, e.g.
+	// "(deleted_at IS NULL)", or empty if the index isn't partial.
+	Predicate string
+
+	// HasExpressions reports whether any indexed key is an expression rather
+	// than a plain column, e.g. lower(email).
+	HasExpressions bool
+}
+
+// ListIndexes returns the indexes of table, sorted by name. The table name is
+// resolved as in SQL: it may be schema-qualified ("billing.invoices"), is
+// otherwise looked up in the search path, and must be double-quoted if it
+// isn't lowercase ("\"Orders\"").
+//
+// Returns ErrTableNotFound if the table doesn't exist.
+//
+// Example:
+//
+//	indexes, err := postgres.ListIndexes(ctx, pool, "users")
+//	require.NoError(t, err)
+//	for _, idx := range indexes {
+//	    if idx.Name == "users_active_email_idx" {
+//	        assert.Equal(t, "(deleted_at IS NULL)", idx.Predicate)
+//	    }
+//	}
+func ListIndexes(ctx context.Context, pool *pgxpool.Pool, table string) ([]IndexInfo, error) {
+	var exists bool
+	if err := pool.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("look up table %s: %w", table, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+	}
+
+	rows, err := pool.Query(ctx, `
+        SELECT n.nspname, c.relname, pg_get_indexdef(i.indexrelid),
+               i.indisunique, i.indisprimary,
+               COALESCE(pg_get_expr(i.indpred, i.indrelid), ''),
+               i.indexprs IS NOT NULL
+        FROM pg_index i
+        JOIN pg_class c ON c.oid = i.indexrelid
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        WHERE i.indrelid = to_regclass($1)
+        ORDER BY c.relname
+    `, table)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes of %s: %w", table, err)
+	}
+
+	var idx IndexInfo
+	var indexes []IndexInfo
+	_, err = pgx.ForEachRow(rows, []any{
+		&idx.Schema, &idx.Name, &idx.Definition,
+		&idx.Unique, &idx.Primary, &idx.Predicate, &idx.HasExpressions,
+	}, func() error {
+		indexes = append(indexes, idx)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list indexes of %s: %w", table, err)
+	}
+	return indexes, nil
+}
+
+// IndexExists reports whether table has an index named indexName. The table
+// is resolved as by ListIndexes.
+//
+// Returns ErrTableNotFound if the table doesn't exist, so a typo in the table
+// name isn't mistaken for a missing index.
+//
+// Example:
+//
+//	ok, err := postgres.IndexExists(ctx, pool, "orders", "orders_user_id_idx")
+//	require.NoError(t, err)
+//	assert.True(t, ok, "migration should index orders.user_id")
+func IndexExists(ctx context.Context, pool *pgxpool.Pool, table, indexName string) (bool, error) {
+	indexes, err := ListIndexes(ctx, pool, table)
+	if err != nil {
+		return false, err
+	}
+	for _, idx := range indexes {
+		if idx.Name == indexName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,87 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestListIndexes(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT NOT NULL, deleted_at TIMESTAMPTZ);
+		CREATE UNIQUE INDEX users_active_email_idx ON users (email) WHERE deleted_at IS NULL;
+		CREATE INDEX users_lower_email_idx ON users (lower(email));
+	`)
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	indexes, err := postgres.ListIndexes(ctx, pool, "users")
+	if err != nil {
+		t.Fatalf("ListIndexes failed: %v", err)
+	}
+
+	want := map[string]postgres.IndexInfo{
+		"users_active_email_idx": {Unique: true, Predicate: "(deleted_at IS NULL)"},
+		"users_lower_email_idx":  {HasExpressions: true},
+		"users_pkey":             {Unique: true, Primary: true},
+	}
+	if len(indexes) != len(want) {
+		t.Fatalf("expected %d indexes, got %+v", len(want), indexes)
+	}
+	for _, idx := range indexes {
+		w, ok := want[idx.Name]
+		if !ok {
+			t.Errorf("unexpected index %s", idx.Name)
+			continue
+		}
+		if idx.Schema != "public" || !strings.HasPrefix(idx.Definition, "CREATE ") {
+			t.Errorf("%s: unexpected schema %q or definition %q", idx.Name, idx.Schema, idx.Definition)
+		}
+		if idx.Unique != w.Unique || idx.Primary != w.Primary || idx.Predicate != w.Predicate || idx.HasExpressions != w.HasExpressions {
+			t.Errorf("%s: got %+v, want %+v", idx.Name, idx, w)
+		}
+	}
+}
+
+func TestIndexExists(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE orders (id SERIAL PRIMARY KEY, user_id INT NOT NULL);
+		CREATE INDEX orders_user_id_idx ON orders (user_id);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	tests := map[string]struct {
+		table, index string
+		want         bool
+		wantErr      error
+	}{
+		"existing index":  {table: "orders", index: "orders_user_id_idx", want: true},
+		"qualified table": {table: "public.orders", index: "orders_pkey", want: true},
+		"missing index":   {table: "orders", index: "orders_created_at_idx", want: false},
+		"missing table":   {table: "invoices", index: "orders_user_id_idx", wantErr: postgres.ErrTableNotFound},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := postgres.IndexExists(ctx, pool, tc.table, tc.index)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
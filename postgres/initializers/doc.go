@@ -0,0 +1,20 @@
+// Package initializers provides testdb.DBInitializer implementations for
+// popular PostgreSQL libraries beyond pgx and database/sql.
+//
+// They live in a separate package so that importing
+// github.com/bashhack/testdb/postgres does not pull sqlx or ent into your build.
+//
+// SqlxInitializer - Creates *sqlx.DB for struct scanning:
+//
+//	db := postgres.New(t, &initializers.SqlxInitializer{})
+//	sqlxDB := db.Entity().(*sqlx.DB)
+//
+// EntInitializer - Creates an ent SQL driver for your generated client:
+//
+//	db := postgres.New(t, &initializers.EntInitializer{})
+//	client := ent.NewClient(ent.Driver(db.Entity().(*entsql.Driver)))
+//
+// Both open the database with postgres.OpenDB, so they use pgx/v5/stdlib as
+// the underlying database/sql driver, honor the same connection options as
+// postgres.SqlDbInitializer, and close the database if initialization fails.
+package initializers
@@ -0,0 +1,41 @@
+package initializers
+
+import (
+	"context"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/bashhack/testdb/postgres"
+)
+
+// EntInitializer creates an ent SQL driver (*entsql.Driver from
+// entgo.io/ent/dialect/sql) for the test database.
+//
+// ent clients are generated per project, so the initializer returns the driver
+// and you construct your own client from it. The driver implements io.Closer,
+// so postgres.New closes it automatically during cleanup.
+//
+// Example:
+//
+//	db := postgres.New(t, &initializers.EntInitializer{})
+//	drv := db.Entity().(*entsql.Driver)
+//	client := ent.NewClient(ent.Driver(drv))
+//
+//	// Don't call client.Close() - cleanup closes the driver
+//	u, err := client.User.Create().SetEmail("test@example.com").Save(ctx)
+type EntInitializer struct{}
+
+// InitializeTestDatabase opens a *sql.DB using the "pgx" driver (pgx/v5/stdlib)
+// and wraps it in an ent driver for the PostgreSQL dialect.
+// The connection is opened and verified by postgres.OpenDB.
+//
+// Returns an error if the connection cannot be established or verified.
+// On error, the database connection is automatically closed.
+func (ei *EntInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
+	db, err := postgres.OpenDB(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return entsql.OpenDB(dialect.Postgres, db), nil
+}
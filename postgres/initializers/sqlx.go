@@ -0,0 +1,36 @@
+package initializers
+
+import (
+	"context"
+
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jmoiron/sqlx"
+)
+
+// SqlxInitializer creates a *sqlx.DB connection using pgx's database/sql driver.
+//
+// Use SqlxInitializer when your application code uses sqlx for struct scanning
+// and named queries.
+//
+// Example:
+//
+//	db := postgres.New(t, &initializers.SqlxInitializer{})
+//	sqlxDB := db.Entity().(*sqlx.DB)
+//
+//	var users []User
+//	err := sqlxDB.Select(&users, "SELECT * FROM users ORDER BY id")
+type SqlxInitializer struct{}
+
+// InitializeTestDatabase creates a *sqlx.DB using the "pgx" driver (pgx/v5/stdlib).
+// The connection is opened and verified by postgres.OpenDB.
+//
+// Returns an error if the connection cannot be established or verified.
+// On error, the database connection is automatically closed.
+func (si *SqlxInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
+	db, err := postgres.OpenDB(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlx.NewDb(db, "pgx"), nil
+}
@@ -0,0 +1,117 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestDropAllTestDatabasesEmptyPrefix(t *testing.T) {
+	n, err := postgres.DropAllTestDatabases(context.Background(), testAdminDSN(), "")
+	if !errors.Is(err, postgres.ErrEmptyPrefix) {
+		t.Fatalf("expected ErrEmptyPrefix, got %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected 0 databases dropped, got %d", n)
+	}
+}
+
+func TestDropAllTestDatabases(t *testing.T) {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("dropall_%d", time.Now().UnixNano())
+
+	// Create databases with the low-level API and never close them, simulating
+	// databases leaked by interrupted test runs.
+	var names []string
+	for range 3 {
+		db, err := testdb.New(t, &postgres.PostgresProvider{}, nil, testdb.WithDBPrefix(prefix))
+		if err != nil {
+			t.Fatalf("failed to create database: %v", err)
+		}
+		names = append(names, db.Name())
+	}
+
+	// A database sharing the prefix text but not followed by '_' must survive
+	other := &postgres.PostgresProvider{}
+	if err := other.Initialize(ctx, testdb.Config{AdminDSNOverride: testAdminDSN()}); err != nil {
+		t.Fatalf("failed to initialize provider: %v", err)
+	}
+	defer func() { _ = other.Cleanup(ctx) }()
+
+	survivor := prefix + "x_keep"
+	if err := other.CreateDatabase(ctx, survivor); err != nil {
+		t.Fatalf("failed to create survivor database: %v", err)
+	}
+	defer func() { _ = other.DropDatabase(ctx, survivor) }()
+
+	n, err := postgres.DropAllTestDatabases(ctx, testAdminDSN(), prefix)
+	if err != nil {
+		t.Fatalf("DropAllTestDatabases failed: %v", err)
+	}
+	if n != len(names) {
+		t.Errorf("expected %d databases dropped, got %d", len(names), n)
+	}
+
+	// Running again finds nothing left to drop
+	n, err = postgres.DropAllTestDatabases(ctx, testAdminDSN(), prefix)
+	if err != nil {
+		t.Fatalf("second DropAllTestDatabases failed: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected 0 databases dropped on second run, got %d", n)
+	}
+}
+
+func TestCleanupLeakedEmptyPrefix(t *testing.T) {
+	_, err := postgres.CleanupLeaked(context.Background(), testAdminDSN(), "", time.Hour)
+	if !errors.Is(err, postgres.ErrEmptyPrefix) {
+		t.Fatalf("expected ErrEmptyPrefix, got %v", err)
+	}
+}
+
+func TestCleanupLeaked(t *testing.T) {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("leaked_%d", time.Now().UnixNano())
+
+	provider := &postgres.PostgresProvider{}
+	if err := provider.Initialize(ctx, testdb.Config{AdminDSNOverride: testAdminDSN()}); err != nil {
+		t.Fatalf("failed to initialize provider: %v", err)
+	}
+	defer func() { _ = provider.Cleanup(ctx) }()
+
+	// A database "created" two hours ago by a crashed run
+	leaked := fmt.Sprintf("%s_%d_deadbeef", prefix, time.Now().Add(-2*time.Hour).UnixNano())
+	if err := provider.CreateDatabase(ctx, leaked); err != nil {
+		t.Fatalf("failed to create leaked database: %v", err)
+	}
+	defer func() { _ = provider.DropDatabase(ctx, leaked) }()
+
+	// A database in use by a currently running test
+	current, err := testdb.New(t, &postgres.PostgresProvider{}, nil, testdb.WithDBPrefix(prefix))
+	if err != nil {
+		t.Fatalf("failed to create current database: %v", err)
+	}
+	defer func() { _ = current.Close() }()
+
+	n, err := postgres.CleanupLeaked(ctx, testAdminDSN(), prefix, time.Hour)
+	if err != nil {
+		t.Fatalf("CleanupLeaked failed: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 leaked database dropped, got %d", n)
+	}
+
+	// The current database was left alone
+	n, err = postgres.DropAllTestDatabases(ctx, testAdminDSN(), prefix)
+	if err != nil {
+		t.Fatalf("DropAllTestDatabases failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected the current database to survive CleanupLeaked, dropped %d", n)
+	}
+}
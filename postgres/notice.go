@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// WithNoticeHandler calls handler with every notice and warning the server
+// sends on the test entity's connections, such as messages from RAISE NOTICE
+// in a function or trigger. pgx discards them otherwise. Use a NoticeRecorder
+// to collect them for assertions.
+//
+// The handler runs on the connection's goroutine, so it must be safe for
+// concurrent use when the entity has several connections. PoolInitializer
+// sets it as pgx's OnNotice before ConfigModifier runs; OpenDB (and so
+// SqlDbInitializer and the postgres/initializers package) sets it too.
+//
+// Example:
+//
+//	var notices postgres.NoticeRecorder
+//	pool := postgres.Setup(t, postgres.WithNoticeHandler(notices.Handle))
+//
+//	_, err := pool.Exec(ctx, "SELECT archive_user($1)", id)
+//	// ...
+//	if got := notices.Messages(); !slices.Contains(got, "user archived") {
+//	    t.Errorf("expected an archive notice, got %q", got)
+//	}
+func WithNoticeHandler(handler func(*pgconn.Notice)) testdb.Option {
+	return func(c *testdb.Config) {
+		c.NoticeHandler = handler
+	}
+}
+
+// noticeHandler adapts handler to pgx's OnNotice.
+func noticeHandler(handler func(*pgconn.Notice)) pgconn.NoticeHandler {
+	return func(_ *pgconn.PgConn, n *pgconn.Notice) {
+		handler(n)
+	}
+}
+
+// NoticeRecorder collects server notices for later assertions. Pass its
+// Handle method to WithNoticeHandler. The zero value is ready to use, and it
+// is safe for concurrent use.
+type NoticeRecorder struct {
+	mu      sync.Mutex
+	notices []*pgconn.Notice
+}
+
+// Handle records n. It has the signature WithNoticeHandler expects.
+func (r *NoticeRecorder) Handle(n *pgconn.Notice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notices = append(r.notices, n)
+}
+
+// Notices returns the notices recorded so far, oldest first.
+func (r *NoticeRecorder) Notices() []*pgconn.Notice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.notices)
+}
+
+// Messages returns the messages of the notices recorded so far, oldest first.
+func (r *NoticeRecorder) Messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	messages := make([]string, len(r.notices))
+	for i, n := range r.notices {
+		messages[i] = n.Message
+	}
+	return messages
+}
+
+// Reset discards the recorded notices.
+func (r *NoticeRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notices = nil
+}
@@ -0,0 +1,72 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestNoticeRecorder(t *testing.T) {
+	var rec postgres.NoticeRecorder
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec.Handle(&pgconn.Notice{Severity: "NOTICE", Message: "hello"})
+		}()
+	}
+	wg.Wait()
+
+	if got := len(rec.Notices()); got != 10 {
+		t.Errorf("expected 10 notices, got %d", got)
+	}
+
+	rec.Reset()
+	rec.Handle(&pgconn.Notice{Severity: "WARNING", Message: "careful"})
+	if got := rec.Messages(); !reflect.DeepEqual(got, []string{"careful"}) {
+		t.Errorf("expected only the notice recorded after Reset, got %q", got)
+	}
+}
+
+func TestWithNoticeHandler(t *testing.T) {
+	const raise = "DO $$ BEGIN RAISE NOTICE 'hello from %', 'plpgsql'; RAISE WARNING 'careful'; END $$"
+
+	tests := map[string]testdb.DBInitializer{
+		"pool":         &postgres.PoolInitializer{},
+		"database/sql": &postgres.SqlDbInitializer{},
+	}
+
+	for name, initializer := range tests {
+		t.Run(name, func(t *testing.T) {
+			var rec postgres.NoticeRecorder
+			db := postgres.New(t, initializer, postgres.WithNoticeHandler(rec.Handle))
+
+			var err error
+			switch entity := db.Entity().(type) {
+			case *pgxpool.Pool:
+				_, err = entity.Exec(context.Background(), raise)
+			case *sql.DB:
+				_, err = entity.Exec(raise)
+			}
+			if err != nil {
+				t.Fatalf("failed to raise notices: %v", err)
+			}
+
+			if got, want := rec.Messages(), []string{"hello from plpgsql", "careful"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("expected messages %q, got %q", want, got)
+			}
+			if notices := rec.Notices(); len(notices) == 2 && notices[1].Severity != "WARNING" {
+				t.Errorf("expected the second notice to be a WARNING, got %s", notices[1].Severity)
+			}
+		})
+	}
+}
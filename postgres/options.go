@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bashhack/testdb"
+)
+
+// WithDefaultQueryTimeout gives every query on the test entity a deadline by
+// setting statement_timeout on each connection it opens, so a hung query fails
+// the test with "canceling statement due to statement timeout" instead of
+// hanging the suite. It is server-side: it needs no context plumbing in the
+// code under test, and applies to *pgxpool.Pool and *sql.DB alike.
+//
+// It is built on testdb.WithConnInitSQL, so it applies to the initializers
+// that support that option. Durations are rounded up to whole milliseconds;
+// d <= 0 disables the timeout (statement_timeout = 0).
+//
+// Example:
+//
+//	pool := postgres.Setup(t, postgres.WithDefaultQueryTimeout(5*time.Second))
+func WithDefaultQueryTimeout(d time.Duration) testdb.Option {
+	return testdb.WithConnInitSQL(fmt.Sprintf("SET statement_timeout = %d", timeoutMillis(d)))
+}
+
+// WithDefaultIsolationLevel starts every transaction on the test entity at
+// the given isolation level by setting default_transaction_isolation on each
+// connection it opens. Tests of serialization-failure handling can use
+// sql.LevelSerializable or sql.LevelRepeatableRead without passing the level
+// to every Begin.
+//
+// Like WithDefaultQueryTimeout, it is built on testdb.WithConnInitSQL.
+// sql.LevelDefault keeps the server's default; levels PostgreSQL doesn't
+// have, such as sql.LevelSnapshot, fail the initializer's connection.
+//
+// Example:
+//
+//	pool := postgres.Setup(t, postgres.WithDefaultIsolationLevel(sql.LevelSerializable))
+func WithDefaultIsolationLevel(level sql.IsolationLevel) testdb.Option {
+	return testdb.WithConnInitSQL(defaultIsolationSQL(level))
+}
+
+// WithLockTimeout sets lock_timeout both on the connections migrations run on
+// and on every connection the test entity opens, so DDL such as CREATE INDEX
+// or ALTER TABLE waiting on another connection's lock fails with "canceling
+// statement due to lock timeout" (SQLSTATE 55P03) instead of hanging. This
+// matters most with schema isolation, where tests share one database and can
+// block each other's locks.
+//
+// Migrations get it as with testdb.WithMigrationLockTimeout; the test entity
+// gets it as a run-time parameter, as with WithSessionParams. Durations are
+// rounded up to whole milliseconds; d <= 0 disables the timeout on the test
+// entity and leaves migrations at the server default.
+//
+// Example:
+//
+//	pool := postgres.Setup(t, postgres.WithLockTimeout(2*time.Second))
+func WithLockTimeout(d time.Duration) testdb.Option {
+	sessionParams := WithSessionParams(map[string]string{
+		"lock_timeout": strconv.FormatInt(timeoutMillis(d), 10),
+	})
+	return func(c *testdb.Config) {
+		c.MigrationLockTimeout = d
+		sessionParams(c)
+	}
+}
+
+// defaultIsolationSQL returns the statement setting default_transaction_isolation
+// to level. The lowercased names of the sql.Level constants are PostgreSQL's
+// setting values ("read committed", "serializable", ...).
+func defaultIsolationSQL(level sql.IsolationLevel) string {
+	if level == sql.LevelDefault {
+		return "SET default_transaction_isolation = DEFAULT"
+	}
+	return fmt.Sprintf("SET default_transaction_isolation = '%s'", strings.ToLower(level.String()))
+}
+
+// WithConnMaxIdleTime sets how long a connection of the test entity may stay
+// idle before it is closed, overriding PoolInitializer's 30 second default.
+// Keep it below any idle timeout between the tests and the server (e.g. a
+// firewall or proxy dropping idle connections), so a stale connection is
+// replaced instead of failing the first query after an idle gap with
+// "unexpected EOF" or "connection reset by peer".
+//
+// PoolInitializer applies it as pgxpool's MaxConnIdleTime, unless the DSN sets
+// pool_max_conn_idle_time; OpenDB (and so SqlDbInitializer and the
+// postgres/initializers package) applies it with sql.DB.SetConnMaxIdleTime.
+// d <= 0 keeps the initializer's default.
+//
+// Example:
+//
+//	pool := postgres.Setup(t, postgres.WithConnMaxIdleTime(10*time.Second))
+func WithConnMaxIdleTime(d time.Duration) testdb.Option {
+	return func(c *testdb.Config) {
+		c.ConnMaxIdleTime = d
+	}
+}
+
+// WithSessionParams sets run-time parameters, such as TimeZone or
+// statement_timeout, on every connection the test entity opens. They are sent
+// when the connection starts (pgx's RuntimeParams), so unlike
+// testdb.WithConnInitSQL they cost no extra round trip.
+//
+// The parameters are merged with those from the test DSN, overriding any of
+// the same name, and calling WithSessionParams more than once merges the
+// maps. PoolInitializer applies them before ConfigModifier runs; OpenDB (and
+// so SqlDbInitializer and the postgres/initializers package) applies them too.
+// Migration tools don't use them.
+//
+// Example:
+//
+//	pool := postgres.Setup(t, postgres.WithSessionParams(map[string]string{
+//	    "TimeZone":          "UTC",
+//	    "statement_timeout": "5s",
+//	}))
+func WithSessionParams(params map[string]string) testdb.Option {
+	return func(c *testdb.Config) {
+		merged := maps.Clone(c.SessionParams)
+		if merged == nil {
+			merged = make(map[string]string, len(params))
+		}
+		maps.Copy(merged, params)
+		c.SessionParams = merged
+	}
+}
+
+// WithValidationQuery makes the initializers verify the test entity's
+// connection by running query instead of pinging the server. pgx's ping is a
+// protocol-level check that some proxies and connection poolers don't pass
+// through, while a plain query does; query can also be a pooler-specific
+// health check.
+//
+// PoolInitializer runs it on the pool, and OpenDB (and so SqlDbInitializer and
+// the postgres/initializers package) on the *sql.DB. Its results are
+// discarded; an error fails the initializer.
+//
+// Example:
+//
+//	pool := postgres.Setup(t, postgres.WithValidationQuery("SELECT 1"))
+func WithValidationQuery(query string) testdb.Option {
+	return func(c *testdb.Config) {
+		c.ValidationQuery = query
+	}
+}
+
+// SSLMode is a libpq sslmode setting, controlling whether and how test
+// connections use TLS.
+type SSLMode string
+
+// SSL modes accepted by WithSSLMode, as documented for libpq.
+const (
+	// SSLModeDisable never uses TLS.
+	SSLModeDisable SSLMode = "disable"
+
+	// SSLModeAllow tries a plain connection first, then TLS.
+	SSLModeAllow SSLMode = "allow"
+
+	// SSLModePrefer tries TLS first, then a plain connection.
+	SSLModePrefer SSLMode = "prefer"
+
+	// SSLModeRequire requires TLS without verifying the server's certificate.
+	SSLModeRequire SSLMode = "require"
+
+	// SSLModeVerifyCA requires TLS and a server certificate signed by a trusted CA.
+	SSLModeVerifyCA SSLMode = "verify-ca"
+
+	// SSLModeVerifyFull is SSLModeVerifyCA and also checks that the server's
+	// host name matches its certificate.
+	SSLModeVerifyFull SSLMode = "verify-full"
+)
+
+// ErrInvalidSSLMode is returned when WithSSLMode is given a mode other than
+// the SSLMode constants.
+var ErrInvalidSSLMode = errors.New("invalid sslmode")
+
+// valid reports whether m is one of the SSLMode constants.
+func (m SSLMode) valid() bool {
+	switch m {
+	case SSLModeDisable, SSLModeAllow, SSLModePrefer, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull:
+		return true
+	}
+	return false
+}
+
+// WithSSLMode sets the sslmode of test DSNs, overriding the one copied from
+// the admin DSN. The admin connection keeps its own, so the admin can connect
+// with sslmode=require while tests verify the server with verify-full, or the
+// other way around.
+//
+// A mode other than the SSLMode constants fails New with ErrInvalidSSLMode,
+// before any database is created.
+//
+// Example:
+//
+//	pool := postgres.Setup(t,
+//	    postgres.WithSSLMode(postgres.SSLModeVerifyFull),
+//	    postgres.WithSSLRootCert("testdata/certs/root.crt"))
+func WithSSLMode(mode SSLMode) testdb.Option {
+	return func(c *testdb.Config) {
+		c.TestSSL.Mode = string(mode)
+	}
+}
+
+// WithSSLRootCert sets the sslrootcert of test DSNs, the file of CA
+// certificates used to verify the server with sslmode verify-ca or
+// verify-full. It overrides the admin DSN's.
+func WithSSLRootCert(path string) testdb.Option {
+	return func(c *testdb.Config) {
+		c.TestSSL.RootCert = path
+	}
+}
+
+// WithSSLCert sets the sslcert of test DSNs, the client certificate file
+// presented to the server. It overrides the admin DSN's.
+func WithSSLCert(path string) testdb.Option {
+	return func(c *testdb.Config) {
+		c.TestSSL.Cert = path
+	}
+}
+
+// WithSSLKey sets the sslkey of test DSNs, the private key file of the client
+// certificate set with WithSSLCert. It overrides the admin DSN's.
+func WithSSLKey(path string) testdb.Option {
+	return func(c *testdb.Config) {
+		c.TestSSL.Key = path
+	}
+}
+
+// timeoutMillis converts d to whole milliseconds for a PostgreSQL timeout
+// setting, rounding up so a positive duration never becomes 0 (disabled).
+func timeoutMillis(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"github.com/bashhack/testdb"
+)
+
+// Dump formats supported by WithRestoreFrom.
+const (
+	// RestoreFormatCustom is a pg_dump -Fc archive, restored with pg_restore.
+	RestoreFormatCustom = "custom"
+
+	// RestoreFormatDirectory is a pg_dump -Fd directory, restored with pg_restore.
+	RestoreFormatDirectory = "directory"
+
+	// RestoreFormatTar is a pg_dump -Ft archive, restored with pg_restore.
+	RestoreFormatTar = "tar"
+
+	// RestoreFormatPlain is a plain SQL script, run with psql.
+	RestoreFormatPlain = "plain"
+)
+
+// ErrUnknownRestoreFormat is returned when WithRestoreFrom is given a format
+// other than the RestoreFormat constants.
+var ErrUnknownRestoreFormat = errors.New("unknown restore format")
+
+// WithRestoreFrom restores a pg_dump into each test database right after it is
+// created, before migrations run. For tests that need a large, realistic
+// dataset, restoring a dump is much faster than migrating and seeding.
+//
+// format is one of RestoreFormatCustom, RestoreFormatDirectory and
+// RestoreFormatTar, restored with pg_restore, or RestoreFormatPlain, run with
+// psql. The pg_restore or psql binary must be in PATH. Archives are restored
+// with --no-owner --no-acl, so dumps taken from another server don't depend
+// on its roles. Any error stops the restore, and the returned error includes
+// the tool's output.
+//
+// A restored database isn't supported with schema isolation.
+//
+// Example:
+//
+//	pool := postgres.Setup(t,
+//	    postgres.WithRestoreFrom("testdata/prod-sample.dump", postgres.RestoreFormatCustom),
+//	    postgres.WithRestoreJobs(4))
+func WithRestoreFrom(path, format string) testdb.Option {
+	return func(c *testdb.Config) {
+		c.Restore.Path = path
+		c.Restore.Format = format
+	}
+}
+
+// WithRestoreJobs sets the number of parallel pg_restore jobs used by
+// WithRestoreFrom (pg_restore --jobs). Only the custom and directory formats
+// can be restored in parallel; other formats ignore it.
+//
+// Example:
+//
+//	postgres.WithRestoreJobs(runtime.NumCPU())
+func WithRestoreJobs(n int) testdb.Option {
+	return func(c *testdb.Config) {
+		c.Restore.Jobs = n
+	}
+}
+
+// restoreCommand returns the program and arguments restoring src into the
+// database at dsn.
+func restoreCommand(src testdb.RestoreSource, dsn string) (string, []string, error) {
+	switch src.Format {
+	case RestoreFormatCustom, RestoreFormatDirectory, RestoreFormatTar:
+		args := []string{
+			"--dbname=" + dsn,
+			"--format=" + src.Format,
+			"--no-owner",
+			"--no-acl",
+			"--exit-on-error",
+		}
+		if src.Jobs > 1 && src.Format != RestoreFormatTar {
+			args = append(args, "--jobs="+strconv.Itoa(src.Jobs))
+		}
+		return "pg_restore", append(args, src.Path), nil
+	case RestoreFormatPlain:
+		return "psql", []string{
+			"--dbname=" + dsn,
+			"--no-psqlrc",
+			"--quiet",
+			"--set=ON_ERROR_STOP=1",
+			"--file=" + src.Path,
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("%w: %q", ErrUnknownRestoreFormat, src.Format)
+	}
+}
+
+// restore restores the configured dump into the newly created database.
+func (p *PostgresProvider) restore(ctx context.Context, name string) error {
+	if _, ok := p.schemas[name]; ok {
+		return fmt.Errorf("restore from %s: %w", p.restoreSource.Path, testdb.ErrIsolationNotSupported)
+	}
+
+	dsn, err := p.BuildDSN(name)
+	if err != nil {
+		return fmt.Errorf("restore from %s: %w", p.restoreSource.Path, err)
+	}
+
+	program, args, err := restoreCommand(p.restoreSource, dsn)
+	if err != nil {
+		return err
+	}
+
+	output, err := exec.CommandContext(ctx, program, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("restore from %s: %s failed: %w\nOutput: %s", p.restoreSource.Path, program, err, output)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestWithRestoreFrom(t *testing.T) {
+	if _, err := exec.LookPath("psql"); err != nil {
+		t.Skip("psql not found in PATH, skipping test")
+	}
+
+	pool := postgres.Setup(t,
+		postgres.WithRestoreFrom("../testdata/postgres/restore/plain.sql", postgres.RestoreFormatPlain))
+
+	var count int
+	if err := pool.QueryRow(context.Background(), "SELECT count(*) FROM products").Scan(&count); err != nil {
+		t.Fatalf("expected restored products table: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 restored rows, got %d", count)
+	}
+}
+
+func TestWithRestoreFromMissingFile(t *testing.T) {
+	if _, err := exec.LookPath("psql"); err != nil {
+		t.Skip("psql not found in PATH, skipping test")
+	}
+
+	_, err := testdb.New(t, &postgres.PostgresProvider{}, nil,
+		testdb.WithAdminDSN(testAdminDSN()),
+		postgres.WithRestoreFrom("../testdata/postgres/restore/missing.sql", postgres.RestoreFormatPlain))
+	if err == nil {
+		t.Fatal("expected restoring a missing file to fail")
+	}
+	if !strings.Contains(err.Error(), "Output:") {
+		t.Errorf("expected the error to include psql's output, got %v", err)
+	}
+}
+
+func TestWithRestoreFromUnknownFormat(t *testing.T) {
+	_, err := testdb.New(t, &postgres.PostgresProvider{}, nil,
+		postgres.WithRestoreFrom("dump.zip", "zip"))
+	if !errors.Is(err, postgres.ErrUnknownRestoreFormat) {
+		t.Errorf("expected ErrUnknownRestoreFormat, got %v", err)
+	}
+}
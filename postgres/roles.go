@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// createRoles creates the roles configured with testdb.WithRoles, recording
+// the ones it created so dropRoles only drops those. Roles that already exist
+// are left untouched. If creating a role fails, the roles created so far are
+// dropped.
+func (p *PostgresProvider) createRoles(ctx context.Context) error {
+	for _, role := range p.roles {
+		created, err := p.createRole(ctx, role)
+		if err != nil {
+			_ = p.dropRoles(context.WithoutCancel(ctx)) // Best effort cleanup
+			return err
+		}
+		if created {
+			p.createdRoles = append(p.createdRoles, role.Name)
+		}
+	}
+	return nil
+}
+
+// createRole creates a single role and grants its memberships. It reports
+// false without error if the role already exists.
+func (p *PostgresProvider) createRole(ctx context.Context, role testdb.RoleSpec) (bool, error) {
+	quotedRole := pgx.Identifier{role.Name}.Sanitize()
+
+	sql := "CREATE ROLE " + quotedRole
+	if len(role.Attributes) > 0 {
+		sql += " WITH " + strings.Join(role.Attributes, " ")
+	}
+
+	if _, err := p.admin.Exec(ctx, sql); err != nil {
+		// 42710 duplicate_object; 23505 unique_violation when a concurrent
+		// CREATE ROLE with the same name wins the race.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && (pgErr.Code == "42710" || pgErr.Code == "23505") {
+			return false, nil
+		}
+		return false, fmt.Errorf("create role %s: %w", role.Name, err)
+	}
+
+	for _, parent := range role.MemberOf {
+		grant := fmt.Sprintf("GRANT %s TO %s", pgx.Identifier{parent}.Sanitize(), quotedRole)
+		if _, err := p.admin.Exec(ctx, grant); err != nil {
+			// The role exists now, so it must be dropped with the others
+			p.createdRoles = append(p.createdRoles, role.Name)
+			return false, fmt.Errorf("grant %s to role %s: %w", parent, role.Name, err)
+		}
+	}
+
+	return true, nil
+}
+
+// grantDatabasePrivileges grants each configured role its database privileges
+// on the test database. It is a no-op under schema isolation.
+func (p *PostgresProvider) grantDatabasePrivileges(ctx context.Context, name string) error {
+	if _, ok := p.schemas[name]; ok {
+		return nil
+	}
+
+	quotedName := pgx.Identifier{name}.Sanitize()
+	for _, role := range p.roles {
+		if len(role.DatabasePrivileges) == 0 {
+			continue
+		}
+		grant := fmt.Sprintf("GRANT %s ON DATABASE %s TO %s",
+			strings.Join(role.DatabasePrivileges, ", "), quotedName, pgx.Identifier{role.Name}.Sanitize())
+		if _, err := p.admin.Exec(ctx, grant); err != nil {
+			return fmt.Errorf("grant database privileges to role %s: %w", role.Name, err)
+		}
+	}
+	return nil
+}
+
+// dropRoles drops the roles created by createRoles, in reverse creation order.
+// It attempts every role and returns the first error.
+func (p *PostgresProvider) dropRoles(ctx context.Context) error {
+	var firstErr error
+	for i := len(p.createdRoles) - 1; i >= 0; i-- {
+		role := p.createdRoles[i]
+		if _, err := p.admin.Exec(ctx, "DROP ROLE IF EXISTS "+pgx.Identifier{role}.Sanitize()); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("drop role %s: %w", role, err)
+			}
+		}
+	}
+	p.createdRoles = nil
+	return firstErr
+}
+
+// setRoleConnLimits applies the connection limits configured with
+// testdb.WithRoleConnLimit, recording each role's previous limit so
+// restoreRoleConnLimits can put it back. Roles are processed in name order.
+func (p *PostgresProvider) setRoleConnLimits(ctx context.Context) error {
+	for _, role := range slices.Sorted(maps.Keys(p.roleConnLimits)) {
+		var previous int
+		err := p.admin.QueryRow(ctx, "SELECT rolconnlimit FROM pg_roles WHERE rolname = $1", role).Scan(&previous)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("set connection limit: role %s does not exist", role)
+		}
+		if err != nil {
+			return fmt.Errorf("set connection limit for role %s: %w", role, err)
+		}
+
+		if err := p.alterRoleConnLimit(ctx, role, p.roleConnLimits[role]); err != nil {
+			return err
+		}
+		if p.savedLimits == nil {
+			p.savedLimits = make(map[string]int)
+		}
+		p.savedLimits[role] = previous
+	}
+	return nil
+}
+
+// restoreRoleConnLimits restores the connection limits changed by
+// setRoleConnLimits, skipping roles this provider created (they are about to
+// be dropped). It attempts every role and returns the first error.
+func (p *PostgresProvider) restoreRoleConnLimits(ctx context.Context) error {
+	var firstErr error
+	for _, role := range slices.Sorted(maps.Keys(p.savedLimits)) {
+		if slices.Contains(p.createdRoles, role) {
+			continue
+		}
+		if err := p.alterRoleConnLimit(ctx, role, p.savedLimits[role]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	p.savedLimits = nil
+	return firstErr
+}
+
+// alterRoleConnLimit sets the CONNECTION LIMIT of role.
+func (p *PostgresProvider) alterRoleConnLimit(ctx context.Context, role string, limit int) error {
+	sql := fmt.Sprintf("ALTER ROLE %s CONNECTION LIMIT %d", pgx.Identifier{role}.Sanitize(), limit)
+	if _, err := p.admin.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("set connection limit for role %s: %w", role, err)
+	}
+	return nil
+}
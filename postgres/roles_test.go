@@ -0,0 +1,161 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestWithRoles(t *testing.T) {
+	ctx := context.Background()
+
+	adminPool, err := pgxpool.New(ctx, testAdminDSN())
+	if err != nil {
+		t.Fatalf("failed to connect as admin: %v", err)
+	}
+	defer adminPool.Close()
+
+	suffix := time.Now().UnixNano()
+	reader := fmt.Sprintf("reader_%d", suffix)
+	user := fmt.Sprintf("App-User_%d", suffix) // exercises identifier quoting
+	existing := fmt.Sprintf("existing_%d", suffix)
+
+	if _, err := adminPool.Exec(ctx, "CREATE ROLE "+pgx.Identifier{existing}.Sanitize()); err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = adminPool.Exec(context.Background(), "DROP ROLE IF EXISTS "+pgx.Identifier{existing}.Sanitize())
+	})
+
+	roleExists := func(name string) bool {
+		t.Helper()
+		var exists bool
+		if err := adminPool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", name).Scan(&exists); err != nil {
+			t.Fatalf("failed to check role %s: %v", name, err)
+		}
+		return exists
+	}
+
+	db, err := testdb.New(t, &postgres.PostgresProvider{}, nil,
+		testdb.WithRoles([]testdb.RoleSpec{
+			{Name: reader, Attributes: []string{"NOLOGIN"}},
+			{Name: user, Attributes: []string{"LOGIN"}, MemberOf: []string{reader}, DatabasePrivileges: []string{"CONNECT", "TEMPORARY"}},
+			{Name: existing, Attributes: []string{"LOGIN"}},
+		}),
+		testdb.WithDBOwner(reader))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	var canLogin, isMember, canConnect bool
+	err = adminPool.QueryRow(ctx, `
+        SELECT r.rolcanlogin, pg_has_role($1, $2, 'MEMBER'), has_database_privilege($1, $3, 'CONNECT')
+        FROM pg_roles r WHERE r.rolname = $1
+    `, user, reader, db.Name()).Scan(&canLogin, &isMember, &canConnect)
+	if err != nil {
+		t.Fatalf("failed to inspect role: %v", err)
+	}
+	if !canLogin || !isMember || !canConnect {
+		t.Errorf("expected %s to be a LOGIN member of %s with CONNECT, got login=%v member=%v connect=%v",
+			user, reader, canLogin, isMember, canConnect)
+	}
+
+	var owner string
+	err = adminPool.QueryRow(ctx,
+		"SELECT pg_get_userbyid(datdba) FROM pg_database WHERE datname = $1", db.Name()).Scan(&owner)
+	if err != nil {
+		t.Fatalf("failed to look up owner: %v", err)
+	}
+	if owner != reader {
+		t.Errorf("expected database owned by %s, got %s", reader, owner)
+	}
+
+	var existingCanLogin bool
+	if err := adminPool.QueryRow(ctx, "SELECT rolcanlogin FROM pg_roles WHERE rolname = $1", existing).Scan(&existingCanLogin); err != nil {
+		t.Fatalf("failed to inspect existing role: %v", err)
+	}
+	if existingCanLogin {
+		t.Error("expected existing role to be left unchanged")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if roleExists(reader) || roleExists(user) {
+		t.Error("expected created roles to be dropped on cleanup")
+	}
+	if !roleExists(existing) {
+		t.Error("expected pre-existing role to be kept on cleanup")
+	}
+}
+
+func TestWithRoleConnLimit(t *testing.T) {
+	ctx := context.Background()
+
+	adminPool, err := pgxpool.New(ctx, testAdminDSN())
+	if err != nil {
+		t.Fatalf("failed to connect as admin: %v", err)
+	}
+	defer adminPool.Close()
+
+	limited := fmt.Sprintf("limited_%d", time.Now().UnixNano())
+	if _, err := adminPool.Exec(ctx, "CREATE ROLE "+pgx.Identifier{limited}.Sanitize()+" LOGIN PASSWORD 'limited' CONNECTION LIMIT 5"); err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = adminPool.Exec(context.Background(), "DROP ROLE IF EXISTS "+pgx.Identifier{limited}.Sanitize())
+	})
+
+	db, err := testdb.New(t, &postgres.PostgresProvider{}, nil,
+		testdb.WithRoles([]testdb.RoleSpec{{Name: limited, DatabasePrivileges: []string{"CONNECT"}}}),
+		testdb.WithRoleConnLimit(limited, 1))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	dsn, err := db.RoleDSN(limited, "limited")
+	if err != nil {
+		t.Fatalf("RoleDSN failed: %v", err)
+	}
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse DSN: %v", err)
+	}
+
+	first, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect as %s: %v", limited, err)
+	}
+
+	second, err := pgx.ConnectConfig(ctx, config)
+	if err == nil {
+		_ = second.Close(ctx)
+		t.Fatal("expected the second connection to exceed the role's connection limit")
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "53300" {
+		t.Errorf("expected SQLSTATE 53300 (too_many_connections), got %v", err)
+	}
+	_ = first.Close(ctx)
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var limit int
+	if err := adminPool.QueryRow(ctx, "SELECT rolconnlimit FROM pg_roles WHERE rolname = $1", limited).Scan(&limit); err != nil {
+		t.Fatalf("failed to read connection limit: %v", err)
+	}
+	if limit != 5 {
+		t.Errorf("expected the previous connection limit 5 to be restored, got %d", limit)
+	}
+}
@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+// DumpSchema returns a normalized, deterministic text representation of the
+// test database's schema, suitable for comparing against a checked-in golden
+// file to catch unintended schema changes from migrations.
+//
+// The dump covers tables, views and materialized views in all non-system
+// schemas, with their columns (type, nullability, default), constraints and
+// indexes. Everything is sorted by name, including columns, so the output
+// doesn't depend on the order migrations added things in, and catalog details
+// that vary between runs (OIDs, generated database names) are left out.
+// Functions, triggers, sequences and grants are not included.
+//
+// Under schema isolation (testdb.WithSchemaFallback) the test's schema is
+// reported as "public", so the same golden file works for both isolation modes.
+//
+// Example output:
+//
+//	TABLE public.users
+//	  COLUMN email text NOT NULL
+//	  COLUMN id integer NOT NULL DEFAULT nextval('users_id_seq'::regclass)
+//	  CONSTRAINT users_pkey PRIMARY KEY (id)
+//	  INDEX users_pkey CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id)
+//
+// Example:
+//
+//	got, err := postgres.DumpSchema(ctx, db)
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	want, _ := os.ReadFile("testdata/schema.golden")
+//	if got != string(want) {
+//	    t.Errorf("schema changed:\n%s", got)
+//	}
+func DumpSchema(ctx context.Context, td *testdb.TestDatabase) (string, error) {
+	config, err := pgx.ParseConfig(td.DSN())
+	if err != nil {
+		return "", fmt.Errorf("parse DSN: %w", err)
+	}
+	applyTLSConfig(config, td.Config().TLSConfig)
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("connect to test database: %w", err)
+	}
+	defer func() { _ = conn.Close(ctx) }()
+
+	d := &schemaDumper{conn: conn}
+	if td.Isolation() == testdb.IsolationSchema {
+		d.schemas = []string{td.Name()}
+		d.renameSchema = td.Name()
+	} else {
+		rows, err := conn.Query(ctx, `
+            SELECT nspname FROM pg_namespace
+            WHERE nspname NOT IN ('pg_catalog', 'information_schema')
+            AND nspname NOT LIKE 'pg\_toast%' AND nspname NOT LIKE 'pg\_temp\_%'
+        `)
+		if err != nil {
+			return "", fmt.Errorf("list schemas: %w", err)
+		}
+		d.schemas, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return "", fmt.Errorf("list schemas: %w", err)
+		}
+	}
+
+	if err := d.load(ctx); err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
+
+// schemaDumper collects catalog details for DumpSchema.
+type schemaDumper struct {
+	conn    *pgx.Conn
+	schemas []string
+
+	// renameSchema is the schema reported as "public" (under schema isolation).
+	renameSchema string
+
+	// relations maps "schema.name" to the relation's lines, in output order.
+	relations map[string]*dumpedRelation
+}
+
+// dumpedRelation is one table or view in the dump.
+type dumpedRelation struct {
+	kind  string
+	lines []string
+}
+
+// load queries relations, columns, constraints and indexes. Every query is
+// ordered by name so lines are appended deterministically.
+func (d *schemaDumper) load(ctx context.Context) error {
+	d.relations = map[string]*dumpedRelation{}
+
+	rows, err := d.conn.Query(ctx, `
+        SELECT n.nspname, c.relname,
+               CASE c.relkind WHEN 'v' THEN 'VIEW' WHEN 'm' THEN 'MATERIALIZED VIEW' ELSE 'TABLE' END
+        FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        WHERE n.nspname = ANY($1) AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+        ORDER BY n.nspname, c.relname
+    `, d.schemas)
+	if err != nil {
+		return fmt.Errorf("query relations: %w", err)
+	}
+	var schema, name, kind string
+	_, err = pgx.ForEachRow(rows, []any{&schema, &name, &kind}, func() error {
+		d.relations[d.qualify(schema, name)] = &dumpedRelation{kind: kind}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("query relations: %w", err)
+	}
+
+	rows, err = d.conn.Query(ctx, `
+        SELECT n.nspname, c.relname, a.attname,
+               format_type(a.atttypid, a.atttypmod), a.attnotnull,
+               COALESCE(pg_get_expr(ad.adbin, ad.adrelid), '')
+        FROM pg_attribute a
+        JOIN pg_class c ON c.oid = a.attrelid
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+        WHERE n.nspname = ANY($1) AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+        AND a.attnum > 0 AND NOT a.attisdropped
+        ORDER BY n.nspname, c.relname, a.attname
+    `, d.schemas)
+	if err != nil {
+		return fmt.Errorf("query columns: %w", err)
+	}
+	var column, dataType, defaultExpr string
+	var notNull bool
+	_, err = pgx.ForEachRow(rows, []any{&schema, &name, &column, &dataType, &notNull, &defaultExpr}, func() error {
+		line := "COLUMN " + column + " " + dataType
+		if notNull {
+			line += " NOT NULL"
+		}
+		if defaultExpr != "" {
+			line += " DEFAULT " + d.normalize(defaultExpr)
+		}
+		d.add(schema, name, line)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("query columns: %w", err)
+	}
+
+	rows, err = d.conn.Query(ctx, `
+        SELECT n.nspname, c.relname, con.conname, pg_get_constraintdef(con.oid)
+        FROM pg_constraint con
+        JOIN pg_class c ON c.oid = con.conrelid
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        WHERE n.nspname = ANY($1)
+        ORDER BY n.nspname, c.relname, con.conname
+    `, d.schemas)
+	if err != nil {
+		return fmt.Errorf("query constraints: %w", err)
+	}
+	var constraint, definition string
+	_, err = pgx.ForEachRow(rows, []any{&schema, &name, &constraint, &definition}, func() error {
+		d.add(schema, name, "CONSTRAINT "+constraint+" "+d.normalize(definition))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("query constraints: %w", err)
+	}
+
+	rows, err = d.conn.Query(ctx, `
+        SELECT schemaname, tablename, indexname, indexdef
+        FROM pg_indexes
+        WHERE schemaname = ANY($1)
+        ORDER BY schemaname, tablename, indexname
+    `, d.schemas)
+	if err != nil {
+		return fmt.Errorf("query indexes: %w", err)
+	}
+	var index string
+	_, err = pgx.ForEachRow(rows, []any{&schema, &name, &index, &definition}, func() error {
+		d.add(schema, name, "INDEX "+index+" "+d.normalize(definition))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("query indexes: %w", err)
+	}
+
+	return nil
+}
+
+// add appends a line to a relation found by load.
+func (d *schemaDumper) add(schema, name, line string) {
+	if rel, ok := d.relations[d.qualify(schema, name)]; ok {
+		rel.lines = append(rel.lines, line)
+	}
+}
+
+// qualify returns the schema-qualified relation name, renaming the isolated
+// test schema to "public".
+func (d *schemaDumper) qualify(schema, name string) string {
+	if schema == d.renameSchema {
+		schema = "public"
+	}
+	return schema + "." + name
+}
+
+// normalize rewrites references to the isolated test schema inside catalog
+// definitions to "public".
+func (d *schemaDumper) normalize(definition string) string {
+	if d.renameSchema == "" {
+		return definition
+	}
+	quoted := pgx.Identifier{d.renameSchema}.Sanitize()
+	definition = strings.ReplaceAll(definition, quoted+".", "public.")
+	return strings.ReplaceAll(definition, d.renameSchema+".", "public.")
+}
+
+// String renders the dump, one relation per block, ordered by qualified name.
+func (d *schemaDumper) String() string {
+	names := make([]string, 0, len(d.relations))
+	for name := range d.relations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		rel := d.relations[name]
+		b.WriteString(rel.kind + " " + name + "\n")
+		for _, line := range rel.lines {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const schemaDumpDDL = `
+    CREATE TABLE users (
+        id SERIAL PRIMARY KEY,
+        email TEXT NOT NULL UNIQUE
+    );
+    CREATE TABLE orders (
+        id SERIAL PRIMARY KEY,
+        user_id INTEGER NOT NULL REFERENCES users (id),
+        total NUMERIC(10, 2) DEFAULT 0
+    );
+    CREATE INDEX orders_user_id_idx ON orders (user_id);
+`
+
+func TestDumpSchema(t *testing.T) {
+	ctx := context.Background()
+
+	db := postgres.New(t, &postgres.PoolInitializer{})
+	pool := db.Entity().(*pgxpool.Pool)
+	if _, err := pool.Exec(ctx, schemaDumpDDL); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	dump, err := postgres.DumpSchema(ctx, db)
+	if err != nil {
+		t.Fatalf("DumpSchema failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"TABLE public.orders\n",
+		"TABLE public.users\n",
+		"  COLUMN email text NOT NULL\n",
+		"  COLUMN total numeric(10,2) DEFAULT 0\n",
+		"  CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id)\n",
+		"  CONSTRAINT users_email_key UNIQUE (email)\n",
+		"  INDEX orders_user_id_idx CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id)\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("expected dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+
+	// Tables are sorted by name regardless of creation order
+	if strings.Index(dump, "TABLE public.orders") > strings.Index(dump, "TABLE public.users") {
+		t.Errorf("expected tables sorted by name, got:\n%s", dump)
+	}
+
+	t.Run("deterministic across databases", func(t *testing.T) {
+		// Same schema, different creation order
+		other := postgres.New(t, &postgres.PoolInitializer{})
+		otherPool := other.Entity().(*pgxpool.Pool)
+		_, err := otherPool.Exec(ctx, `
+            CREATE TABLE users (
+                email TEXT NOT NULL UNIQUE,
+                id SERIAL PRIMARY KEY
+            );
+            CREATE TABLE orders (
+                id SERIAL PRIMARY KEY,
+                total NUMERIC(10, 2) DEFAULT 0,
+                user_id INTEGER NOT NULL REFERENCES users (id)
+            );
+            CREATE INDEX orders_user_id_idx ON orders (user_id);
+        `)
+		if err != nil {
+			t.Fatalf("failed to create tables: %v", err)
+		}
+
+		otherDump, err := postgres.DumpSchema(ctx, other)
+		if err != nil {
+			t.Fatalf("DumpSchema failed: %v", err)
+		}
+		if otherDump != dump {
+			t.Errorf("expected identical dumps, got:\n%s\nvs:\n%s", dump, otherDump)
+		}
+	})
+
+	t.Run("schema isolation reported as public", func(t *testing.T) {
+		isolated := postgres.New(t, &postgres.PoolInitializer{},
+			testdb.WithAdminDSN(createRestrictedRole(t)),
+			testdb.WithSchemaFallback())
+		isolatedPool := isolated.Entity().(*pgxpool.Pool)
+		if _, err := isolatedPool.Exec(ctx, schemaDumpDDL); err != nil {
+			t.Fatalf("failed to create tables: %v", err)
+		}
+
+		isolatedDump, err := postgres.DumpSchema(ctx, isolated)
+		if err != nil {
+			t.Fatalf("DumpSchema failed: %v", err)
+		}
+		if strings.Contains(isolatedDump, isolated.Name()) {
+			t.Errorf("expected test schema name to be normalized, got:\n%s", isolatedDump)
+		}
+		if isolatedDump != dump {
+			t.Errorf("expected dump to match database isolation, got:\n%s\nvs:\n%s", dump, isolatedDump)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SeedOption configures how Seed and SeedFile load seed data.
+type SeedOption func(*seedConfig)
+
+// seedConfig holds the settings applied by SeedOptions.
+type seedConfig struct {
+	deferConstraints bool
+	disableTriggers  []string
+	disableFKChecks  bool
+	analyze          bool
+}
+
+// WithDeferredConstraints runs SET CONSTRAINTS ALL DEFERRED at the start of the
+// seed transaction, so foreign keys are checked at commit rather than per
+// statement. Seed statements can then insert rows in any order, as long as the
+// data is consistent once the seed completes.
+//
+// Only constraints declared DEFERRABLE are affected; non-deferrable constraints
+// are still checked immediately. Declare foreign keys as
+//
+//	REFERENCES users(id) DEFERRABLE INITIALLY IMMEDIATE
+//
+// to make them deferrable without changing their behavior elsewhere.
+func WithDeferredConstraints() SeedOption {
+	return func(c *seedConfig) {
+		c.deferConstraints = true
+	}
+}
+
+// WithDisabledTriggers disables all triggers on the given tables
+// (ALTER TABLE ... DISABLE TRIGGER ALL) for the duration of the seed and
+// re-enables them before the seed transaction commits. Table names may be
+// schema-qualified ("schema.table").
+//
+// Disabling all triggers includes the internal triggers that enforce foreign
+// keys, so this works for constraints that aren't DEFERRABLE, but rows are not
+// re-checked afterwards and inconsistent seed data will go unnoticed. It
+// requires the test database user to be a superuser, since disabling the
+// internal constraint triggers is a privileged operation.
+func WithDisabledTriggers(tables ...string) SeedOption {
+	return func(c *seedConfig) {
+		c.disableTriggers = append(c.disableTriggers, tables...)
+	}
+}
+
+// WithForeignKeyChecksDisabled runs the seed with session_replication_role set
+// to replica (SET LOCAL, so the setting ends with the seed transaction). In
+// that mode PostgreSQL doesn't fire ordinary triggers, including the ones that
+// enforce foreign keys, so seed statements can insert rows in any order
+// without listing tables or declaring constraints DEFERRABLE.
+//
+// As with WithDisabledTriggers, rows are not re-checked afterwards, and user
+// triggers don't fire during the seed either. Setting
+// session_replication_role requires a superuser, or on PostgreSQL 15+ a role
+// granted SET on the parameter.
+func WithForeignKeyChecksDisabled() SeedOption {
+	return func(c *seedConfig) {
+		c.disableFKChecks = true
+	}
+}
+
+// WithAnalyzeAfterSeed runs ANALYZE on the database once the seed has been
+// committed, so the planner's statistics reflect the seeded data. Without it,
+// plans inspected right after seeding (see ExplainAnalyze) may be based on
+// statistics for empty tables, as autovacuum updates them asynchronously.
+func WithAnalyzeAfterSeed() SeedOption {
+	return func(c *seedConfig) {
+		c.analyze = true
+	}
+}
+
+// Seed executes seed SQL against the database in a single transaction. The SQL
+// may contain multiple statements. If any statement fails, the transaction is
+// rolled back and no seed data is loaded.
+//
+// Example:
+//
+//	err := postgres.Seed(ctx, pool, `
+//	    INSERT INTO orders (id, user_id) VALUES (1, 1);
+//	    INSERT INTO users (id, email) VALUES (1, 'alice@example.com');
+//	`, postgres.WithDeferredConstraints())
+func Seed(ctx context.Context, pool *pgxpool.Pool, sql string, opts ...SeedOption) error {
+	var cfg seedConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if cfg.deferConstraints {
+			if _, err := tx.Exec(ctx, "SET CONSTRAINTS ALL DEFERRED"); err != nil {
+				return fmt.Errorf("defer constraints: %w", err)
+			}
+		}
+
+		if cfg.disableFKChecks {
+			if _, err := tx.Exec(ctx, "SET LOCAL session_replication_role = replica"); err != nil {
+				return fmt.Errorf("disable foreign key checks: %w", err)
+			}
+		}
+
+		for _, table := range cfg.disableTriggers {
+			quoted := quoteTable(table)
+			if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DISABLE TRIGGER ALL", quoted)); err != nil {
+				return fmt.Errorf("disable triggers on %s: %w", table, err)
+			}
+		}
+
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("execute seed: %w", err)
+		}
+
+		for _, table := range cfg.disableTriggers {
+			quoted := quoteTable(table)
+			if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ENABLE TRIGGER ALL", quoted)); err != nil {
+				return fmt.Errorf("enable triggers on %s: %w", table, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.analyze {
+		if _, err := pool.Exec(ctx, "ANALYZE"); err != nil {
+			return fmt.Errorf("analyze: %w", err)
+		}
+	}
+	return nil
+}
+
+// quoteTable quotes a table name, optionally schema-qualified, as an
+// identifier.
+func quoteTable(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
+
+// SeedFile reads a SQL file and executes it with Seed.
+//
+// Example:
+//
+//	err := postgres.SeedFile(ctx, pool, "testdata/fixtures.sql", postgres.WithDeferredConstraints())
+func SeedFile(ctx context.Context, pool *pgxpool.Pool, path string, opts ...SeedOption) error {
+	sql, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read seed file: %w", err)
+	}
+
+	if err := Seed(ctx, pool, string(sql), opts...); err != nil {
+		return fmt.Errorf("seed %s: %w", path, err)
+	}
+	return nil
+}
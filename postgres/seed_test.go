@@ -0,0 +1,179 @@
+package postgres_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// createSeedTables creates a users/orders schema where orders references users
+// with a deferrable foreign key.
+func createSeedTables(t *testing.T, pool *pgxpool.Pool) {
+	t.Helper()
+
+	_, err := pool.Exec(context.Background(), `
+		CREATE TABLE users (id INT PRIMARY KEY, email TEXT NOT NULL);
+		CREATE TABLE orders (
+			id INT PRIMARY KEY,
+			user_id INT NOT NULL REFERENCES users(id) DEFERRABLE INITIALLY IMMEDIATE
+		);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+}
+
+// outOfOrderSeed inserts an order before the user it references.
+const outOfOrderSeed = `
+	INSERT INTO orders (id, user_id) VALUES (1, 1);
+	INSERT INTO users (id, email) VALUES (1, 'alice@example.com');
+`
+
+func countRows(t *testing.T, pool *pgxpool.Pool, table string) int {
+	t.Helper()
+
+	var n int
+	if err := pool.QueryRow(context.Background(), "SELECT count(*) FROM "+table).Scan(&n); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		opts    []postgres.SeedOption
+		wantErr bool
+	}{
+		"out of order fails without options": {
+			wantErr: true,
+		},
+		"deferred constraints": {
+			opts: []postgres.SeedOption{postgres.WithDeferredConstraints()},
+		},
+		"disabled triggers": {
+			opts: []postgres.SeedOption{postgres.WithDisabledTriggers("orders")},
+		},
+		"disabled triggers on schema-qualified table": {
+			opts: []postgres.SeedOption{postgres.WithDisabledTriggers("public.orders")},
+		},
+		"foreign key checks disabled": {
+			opts: []postgres.SeedOption{postgres.WithForeignKeyChecksDisabled()},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pool := postgres.Setup(t)
+			createSeedTables(t, pool)
+
+			err := postgres.Seed(ctx, pool, outOfOrderSeed, tc.opts...)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected foreign key violation")
+				}
+				// The seed transaction is rolled back
+				if n := countRows(t, pool, "orders"); n != 0 {
+					t.Errorf("expected no orders after failed seed, got %d", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Seed failed: %v", err)
+			}
+
+			if n := countRows(t, pool, "orders"); n != 1 {
+				t.Errorf("expected 1 order, got %d", n)
+			}
+			if n := countRows(t, pool, "users"); n != 1 {
+				t.Errorf("expected 1 user, got %d", n)
+			}
+		})
+	}
+}
+
+func TestSeedDeferredConstraintsStillChecked(t *testing.T) {
+	pool := postgres.Setup(t)
+	createSeedTables(t, pool)
+
+	// Deferred constraints are checked at commit, so dangling references fail
+	err := postgres.Seed(context.Background(), pool,
+		"INSERT INTO orders (id, user_id) VALUES (1, 42)",
+		postgres.WithDeferredConstraints())
+	if err == nil {
+		t.Fatal("expected foreign key violation at commit")
+	}
+}
+
+func TestSeedDisabledTriggersReenabled(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+	createSeedTables(t, pool)
+
+	if err := postgres.Seed(ctx, pool, outOfOrderSeed, postgres.WithDisabledTriggers("orders")); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	// Triggers are enabled again, so the foreign key is enforced after seeding
+	_, err := pool.Exec(ctx, "INSERT INTO orders (id, user_id) VALUES (2, 42)")
+	if err == nil {
+		t.Fatal("expected foreign key to be enforced after seeding")
+	}
+}
+
+func TestSeedForeignKeyChecksRestored(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+	createSeedTables(t, pool)
+
+	if err := postgres.Seed(ctx, pool, outOfOrderSeed, postgres.WithForeignKeyChecksDisabled()); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	var role string
+	if err := pool.QueryRow(ctx, "SHOW session_replication_role").Scan(&role); err != nil {
+		t.Fatalf("failed to read session_replication_role: %v", err)
+	}
+	if role != "origin" {
+		t.Errorf("expected session_replication_role to be restored to origin, got %s", role)
+	}
+
+	_, err := pool.Exec(ctx, "INSERT INTO orders (id, user_id) VALUES (2, 42)")
+	if err == nil {
+		t.Fatal("expected foreign key to be enforced after seeding")
+	}
+}
+
+func TestSeedFile(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+	createSeedTables(t, pool)
+
+	path := filepath.Join(t.TempDir(), "seed.sql")
+	if err := os.WriteFile(path, []byte(outOfOrderSeed), 0644); err != nil {
+		t.Fatalf("failed to write seed file: %v", err)
+	}
+
+	if err := postgres.SeedFile(ctx, pool, path, postgres.WithDeferredConstraints()); err != nil {
+		t.Fatalf("SeedFile failed: %v", err)
+	}
+
+	if n := countRows(t, pool, "orders"); n != 1 {
+		t.Errorf("expected 1 order, got %d", n)
+	}
+}
+
+func TestSeedFileMissing(t *testing.T) {
+	pool := postgres.Setup(t)
+
+	err := postgres.SeedFile(context.Background(), pool, filepath.Join(t.TempDir(), "missing.sql"))
+	if err == nil || !strings.Contains(err.Error(), "read seed file") {
+		t.Fatalf("expected read seed file error, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ResetSequences moves the sequence behind every serial and identity column in
+// the connection's search path to just past the column's current maximum
+// value, so the next insert that relies on the default doesn't collide with
+// existing rows. Sequences of empty tables are reset to their start value.
+//
+// Call it after loading seed data with explicit IDs, or after any other
+// inserts that bypass the column default. All sequences are reset with
+// setval in a single batch.
+//
+// Example:
+//
+//	err := postgres.Seed(ctx, pool, `
+//	    INSERT INTO users (id, email) VALUES (1, 'alice@example.com'), (2, 'bob@example.com');
+//	`)
+//	require.NoError(t, err)
+//	require.NoError(t, postgres.ResetSequences(ctx, pool))
+//
+//	// Gets id 3 instead of failing with a duplicate key error
+//	_, err = pool.Exec(ctx, "INSERT INTO users (email) VALUES ('carol@example.com')")
+func ResetSequences(ctx context.Context, pool *pgxpool.Pool) error {
+	// Serial columns own their sequence with an 'a' (auto) dependency, identity
+	// columns with an 'i' (internal) one
+	rows, err := pool.Query(ctx, `
+        SELECT seq_ns.nspname, seq.relname, tbl_ns.nspname, tbl.relname, att.attname, s.seqstart
+        FROM pg_class seq
+        JOIN pg_namespace seq_ns ON seq_ns.oid = seq.relnamespace
+        JOIN pg_sequence s ON s.seqrelid = seq.oid
+        JOIN pg_depend d ON d.classid = 'pg_class'::regclass AND d.objid = seq.oid
+            AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+        JOIN pg_class tbl ON tbl.oid = d.refobjid
+        JOIN pg_namespace tbl_ns ON tbl_ns.oid = tbl.relnamespace
+        JOIN pg_attribute att ON att.attrelid = tbl.oid AND att.attnum = d.refobjsubid
+        WHERE seq.relkind = 'S'
+        AND tbl_ns.nspname = ANY(current_schemas(false))
+        ORDER BY tbl_ns.nspname, tbl.relname, att.attname
+    `)
+	if err != nil {
+		return fmt.Errorf("list sequences: %w", err)
+	}
+
+	var seqSchema, seqName, tableSchema, tableName, column string
+	var start int64
+	var statements []string
+	_, err = pgx.ForEachRow(rows, []any{&seqSchema, &seqName, &tableSchema, &tableName, &column, &start}, func() error {
+		// setval with is_called = false makes the next nextval return the value
+		sequence := strings.ReplaceAll(pgx.Identifier{seqSchema, seqName}.Sanitize(), "'", "''")
+		statements = append(statements, fmt.Sprintf(
+			"SELECT setval('%s', COALESCE(max(%s) + 1, %d), false) FROM %s",
+			sequence, pgx.Identifier{column}.Sanitize(), start, pgx.Identifier{tableSchema, tableName}.Sanitize()))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list sequences: %w", err)
+	}
+
+	if err := ExecBatch(ctx, pool, statements); err != nil {
+		return fmt.Errorf("reset sequences: %w", err)
+	}
+	return nil
+}
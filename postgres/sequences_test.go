@@ -0,0 +1,65 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestResetSequences(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT NOT NULL);
+		CREATE TABLE "Orders" (id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, note TEXT);
+		CREATE TABLE tags (id SERIAL PRIMARY KEY, name TEXT);
+		INSERT INTO tags (name) VALUES ('deleted'), ('also deleted');
+		DELETE FROM tags;
+	`)
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	err = postgres.Seed(ctx, pool, `
+		INSERT INTO users (id, email) VALUES (1, 'alice@example.com'), (7, 'bob@example.com');
+		INSERT INTO "Orders" (id, note) VALUES (41, 'first');
+	`)
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	if err := postgres.ResetSequences(ctx, pool); err != nil {
+		t.Fatalf("ResetSequences failed: %v", err)
+	}
+
+	tests := map[string]struct {
+		insert string
+		wantID int64
+	}{
+		"serial column":   {insert: "INSERT INTO users (email) VALUES ('carol@example.com') RETURNING id", wantID: 8},
+		"identity column": {insert: `INSERT INTO "Orders" (note) VALUES ('second') RETURNING id`, wantID: 42},
+		"empty table":     {insert: "INSERT INTO tags (name) VALUES ('go') RETURNING id", wantID: 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var id int64
+			if err := pool.QueryRow(ctx, tc.insert).Scan(&id); err != nil {
+				t.Fatalf("insert failed: %v", err)
+			}
+			if id != tc.wantID {
+				t.Errorf("expected id %d, got %d", tc.wantID, id)
+			}
+		})
+	}
+}
+
+func TestResetSequencesNoSequences(t *testing.T) {
+	pool := postgres.Setup(t)
+
+	if err := postgres.ResetSequences(context.Background(), pool); err != nil {
+		t.Fatalf("ResetSequences failed: %v", err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"fmt"
 
-	_ "github.com/jackc/pgx/v5/stdlib" // pgx driver for database/sql
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 // SqlDbInitializer creates a standard *sql.DB connection using pgx's database/sql driver.
@@ -36,17 +38,64 @@ import (
 type SqlDbInitializer struct{}
 
 // InitializeTestDatabase creates a *sql.DB using the "pgx" driver (pgx/v5/stdlib).
-// The connection is verified via Ping before being returned.
+// The connection is opened and verified by OpenDB, which applies the
+// connection options in the test database's config.
 //
 // Returns an error if the connection cannot be established or verified.
 // On error, the database connection is automatically closed.
 func (si *SqlDbInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
-	db, err := sql.Open("pgx", dsn)
+	return OpenDB(ctx, dsn)
+}
+
+// OpenDB opens a *sql.DB for dsn through pgx's database/sql driver
+// (pgx/v5/stdlib) and verifies it. It is the building block of the
+// database/sql based initializers and can be used by custom initializers
+// wrapping *sql.DB.
+//
+// OpenDB reads the test database's config from ctx (testdb.ConfigFromContext)
+// and honors:
+//   - ConnectTimeout (testdb.WithConnectTimeout) and TLSConfig
+//     (testdb.WithTLSConfig) for every new connection
+//   - SessionParams (WithSessionParams), sent on every new connection
+//   - NoticeHandler (WithNoticeHandler), receiving every connection's notices
+//   - ConnInitSQL (testdb.WithConnInitSQL), run on every new connection
+//   - ConnMaxIdleTime (WithConnMaxIdleTime), applied with SetConnMaxIdleTime
+//   - ValidationQuery (WithValidationQuery), run to verify the database
+//     instead of a ping
+//
+// On error, the database is closed.
+func OpenDB(ctx context.Context, dsn string) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	if err := db.PingContext(ctx); err != nil {
+	var opts []stdlib.OptionOpenDB
+	cfg, hasCfg := testdb.ConfigFromContext(ctx)
+	if hasCfg {
+		if cfg.ConnectTimeout > 0 {
+			connConfig.ConnectTimeout = cfg.ConnectTimeout
+		}
+		applyTLSConfig(connConfig, cfg.TLSConfig)
+		applySessionParams(connConfig, cfg.SessionParams)
+		if cfg.NoticeHandler != nil {
+			connConfig.OnNotice = noticeHandler(cfg.NoticeHandler)
+		}
+		if len(cfg.ConnInitSQL) > 0 {
+			opts = append(opts, stdlib.OptionAfterConnect(connInitSQLHook(cfg.ConnInitSQL)))
+		}
+	}
+	db := stdlib.OpenDB(*connConfig, opts...)
+	if hasCfg && cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	if hasCfg && cfg.ValidationQuery != "" {
+		if _, err := db.ExecContext(ctx, cfg.ValidationQuery); err != nil {
+			_ = db.Close() // Best effort cleanup
+			return nil, fmt.Errorf("validate connection: %w", err)
+		}
+	} else if err := db.PingContext(ctx); err != nil {
 		_ = db.Close() // Best effort cleanup
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
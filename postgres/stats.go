@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBStats is a snapshot of resource usage for a test database, as reported by
+// PostgreSQL's statistics views.
+type DBStats struct {
+	// SizeBytes is the on-disk size of the database (pg_database_size).
+	SizeBytes int64
+
+	// Tables holds per-table row estimates for user tables, ordered by schema
+	// and table name.
+	Tables []TableStats
+
+	// Connections is the number of backends connected to the database,
+	// including the one used to collect these stats.
+	Connections int
+}
+
+// TableStats holds the row estimate for a single user table.
+type TableStats struct {
+	Schema string
+	Name   string
+
+	// EstimatedRows is the estimated number of live rows (n_live_tup).
+	// Statistics are updated asynchronously, so run ANALYZE on the table
+	// first if an exact count is needed immediately after writing.
+	EstimatedRows int64
+}
+
+// Stats reports the size, per-table row estimates and connection count for the
+// database the pool is connected to.
+//
+// It's useful for performance tests and for checking that seeds or migrations
+// produced the expected data volume:
+//
+//	stats, err := postgres.Stats(ctx, pool)
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	t.Logf("database size: %d bytes across %d tables", stats.SizeBytes, len(stats.Tables))
+func Stats(ctx context.Context, pool *pgxpool.Pool) (DBStats, error) {
+	var stats DBStats
+
+	err := pool.QueryRow(ctx, `
+        SELECT pg_database_size(current_database()),
+               (SELECT count(*) FROM pg_stat_activity WHERE datname = current_database())
+    `).Scan(&stats.SizeBytes, &stats.Connections)
+	if err != nil {
+		return DBStats{}, fmt.Errorf("query database stats: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `
+        SELECT schemaname, relname, n_live_tup
+        FROM pg_stat_user_tables
+        ORDER BY schemaname, relname
+    `)
+	if err != nil {
+		return DBStats{}, fmt.Errorf("query table stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts TableStats
+		if err := rows.Scan(&ts.Schema, &ts.Name, &ts.EstimatedRows); err != nil {
+			return DBStats{}, fmt.Errorf("scan table stats: %w", err)
+		}
+		stats.Tables = append(stats.Tables, ts)
+	}
+	if err := rows.Err(); err != nil {
+		return DBStats{}, fmt.Errorf("read table stats: %w", err)
+	}
+
+	return stats, nil
+}
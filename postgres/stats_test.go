@@ -0,0 +1,63 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	t.Run("empty database", func(t *testing.T) {
+		stats, err := postgres.Stats(ctx, pool)
+		if err != nil {
+			t.Fatalf("Stats failed: %v", err)
+		}
+
+		if stats.SizeBytes <= 0 {
+			t.Errorf("expected positive database size, got %d", stats.SizeBytes)
+		}
+		if stats.Connections < 1 {
+			t.Errorf("expected at least 1 connection, got %d", stats.Connections)
+		}
+		if len(stats.Tables) != 0 {
+			t.Errorf("expected no user tables, got %v", stats.Tables)
+		}
+	})
+
+	t.Run("reports row estimates", func(t *testing.T) {
+		_, err := pool.Exec(ctx, `
+            CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT);
+            CREATE TABLE orders (id SERIAL PRIMARY KEY);
+            INSERT INTO users (name) SELECT 'user' || g FROM generate_series(1, 25) g;
+            ANALYZE users;
+            ANALYZE orders;
+        `)
+		if err != nil {
+			t.Fatalf("failed to seed tables: %v", err)
+		}
+
+		stats, err := postgres.Stats(ctx, pool)
+		if err != nil {
+			t.Fatalf("Stats failed: %v", err)
+		}
+
+		if len(stats.Tables) != 2 {
+			t.Fatalf("expected 2 tables, got %v", stats.Tables)
+		}
+
+		// Ordered by schema, then name
+		expected := []postgres.TableStats{
+			{Schema: "public", Name: "orders", EstimatedRows: 0},
+			{Schema: "public", Name: "users", EstimatedRows: 25},
+		}
+		for i, want := range expected {
+			if got := stats.Tables[i]; got != want {
+				t.Errorf("table %d: expected %+v, got %+v", i, want, got)
+			}
+		}
+	})
+}
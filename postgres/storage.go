@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidStorageParam is returned when WithTableStorageParams is given an
+// invalid table or storage parameter name.
+var ErrInvalidStorageParam = errors.New("invalid table storage parameter")
+
+var (
+	// storageTablePattern matches a table name, optionally schema-qualified.
+	storageTablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+	// storageParamPattern matches a storage parameter name, optionally with a
+	// namespace (e.g. "toast.autovacuum_enabled").
+	storageParamPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+)
+
+// WithTableStorageParams sets storage parameters on tables after migrations
+// run (ALTER TABLE ... SET (...)), so performance tests can tune them without
+// editing migrations, e.g. a lower fillfactor to leave room for HOT updates.
+// params maps table names ("table" or "schema.table") to parameter names and
+// values. Calling WithTableStorageParams more than once merges the maps,
+// including the parameters of a table named in both.
+//
+// Table and parameter names are validated when the provider is initialized,
+// before the test database is created; an invalid name fails setup with
+// ErrInvalidStorageParam. Parameters are applied by Setup, New and NewPool.
+//
+// Example:
+//
+//	pool := postgres.Setup(t,
+//	    testdb.WithMigrations("./migrations"),
+//	    testdb.WithMigrationTool(testdb.MigrationToolGoose),
+//	    postgres.WithTableStorageParams(map[string]map[string]string{
+//	        "accounts": {"fillfactor": "70", "autovacuum_enabled": "off"},
+//	    }))
+func WithTableStorageParams(params map[string]map[string]string) testdb.Option {
+	return func(c *testdb.Config) {
+		merged := maps.Clone(c.TableStorageParams)
+		if merged == nil {
+			merged = make(map[string]map[string]string, len(params))
+		}
+		for table, settings := range params {
+			tableParams := maps.Clone(merged[table])
+			if tableParams == nil {
+				tableParams = make(map[string]string, len(settings))
+			}
+			maps.Copy(tableParams, settings)
+			merged[table] = tableParams
+		}
+		c.TableStorageParams = merged
+	}
+}
+
+// tableStorageParamsSQL returns the ALTER TABLE statements setting params, in
+// table order, after validating table and parameter names. Values are passed
+// as string literals, which PostgreSQL accepts for every storage parameter.
+func tableStorageParamsSQL(params map[string]map[string]string) ([]string, error) {
+	var statements []string
+	for _, table := range slices.Sorted(maps.Keys(params)) {
+		if !storageTablePattern.MatchString(table) {
+			return nil, fmt.Errorf("%w: table name %q", ErrInvalidStorageParam, table)
+		}
+
+		settings := params[table]
+		if len(settings) == 0 {
+			continue
+		}
+
+		var assignments []string
+		for _, name := range slices.Sorted(maps.Keys(settings)) {
+			if !storageParamPattern.MatchString(name) {
+				return nil, fmt.Errorf("%w: parameter name %q for table %s", ErrInvalidStorageParam, name, table)
+			}
+			value := strings.ReplaceAll(settings[name], "'", "''")
+			assignments = append(assignments, fmt.Sprintf("%s = '%s'", name, value))
+		}
+
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s SET (%s)",
+			quoteTable(table), strings.Join(assignments, ", ")))
+	}
+	return statements, nil
+}
+
+// applyTableStorageParams sets the storage parameters configured with
+// WithTableStorageParams on the test database.
+func applyTableStorageParams(ctx context.Context, db *testdb.TestDatabase) error {
+	statements, err := tableStorageParamsSQL(db.Config().TableStorageParams)
+	if err != nil || len(statements) == 0 {
+		return err
+	}
+
+	config, err := pgx.ParseConfig(db.DSN())
+	if err != nil {
+		return fmt.Errorf("parse DSN: %w", err)
+	}
+	applyTLSConfig(config, db.Config().TLSConfig)
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("connect to test database: %w", err)
+	}
+	defer func() { _ = conn.Close(ctx) }()
+
+	for _, sql := range statements {
+		if _, err := conn.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("set table storage parameters: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyTableStorageParamsIfConfigured applies WithTableStorageParams after
+// migrations. It calls t.Fatalf if they can't be applied, so this function
+// does not return on error.
+func applyTableStorageParamsIfConfigured(t testing.TB, db *testdb.TestDatabase, callerName string) {
+	if len(db.Config().TableStorageParams) == 0 {
+		return
+	}
+	if err := applyTableStorageParams(context.Background(), db); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			t.Logf("Warning: failed to close database after storage parameter error: %v", closeErr)
+		}
+		t.Fatalf("%s: %v", callerName, err)
+	}
+}
@@ -0,0 +1,41 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+)
+
+func TestWithTableStorageParams(t *testing.T) {
+	pool := postgres.Setup(t,
+		testdb.WithMigrations("../testdata/postgres/migrations_migrate"),
+		testdb.WithMigrationTool(testdb.MigrationToolMigrate),
+		testdb.WithMigrateInProcess(),
+		postgres.WithTableStorageParams(map[string]map[string]string{
+			"test_table": {"fillfactor": "70"},
+		}))
+
+	var options []string
+	err := pool.QueryRow(context.Background(),
+		"SELECT reloptions FROM pg_class WHERE relname = 'test_table'").Scan(&options)
+	if err != nil {
+		t.Fatalf("failed to query reloptions: %v", err)
+	}
+	if !slices.Contains(options, "fillfactor=70") {
+		t.Errorf("expected fillfactor=70 in reloptions, got %v", options)
+	}
+}
+
+func TestWithTableStorageParamsInvalidName(t *testing.T) {
+	_, err := testdb.New(t, &postgres.PostgresProvider{}, nil,
+		postgres.WithTableStorageParams(map[string]map[string]string{
+			"test_table": {"fillfactor = 70); DROP TABLE test_table; --": "70"},
+		}))
+	if !errors.Is(err, postgres.ErrInvalidStorageParam) {
+		t.Errorf("expected ErrInvalidStorageParam, got %v", err)
+	}
+}
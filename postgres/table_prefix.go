@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TablePrefix returns a unique table name prefix for the calling test, such as
+// "t3f9a1c2e_", and registers a cleanup that drops every table whose name
+// starts with it (see DropTablesWithPrefix).
+//
+// Experimental, and a last resort: use it only where the test user can create
+// neither databases nor schemas, so Setup and schema isolation
+// (testdb.WithIsolation) are unavailable. Tests then share one database and
+// isolate themselves by convention, creating and querying their tables under
+// the prefix. Nothing rewrites names for them: code under test that hardcodes
+// table names sees every test's data, and objects other than tables (types,
+// functions) aren't namespaced or cleaned up.
+//
+// The prefix is lowercase letters, digits and an underscore, so prefixed
+// names can be used unquoted.
+//
+// Example:
+//
+//	pool := sharedPool(t) // a pool for the shared database
+//	prefix := postgres.TablePrefix(t, pool)
+//
+//	_, err := pool.Exec(ctx, "CREATE TABLE "+prefix+"users (id SERIAL PRIMARY KEY, email TEXT)")
+//	require.NoError(t, err)
+func TablePrefix(t testing.TB, pool *pgxpool.Pool) string {
+	t.Helper()
+
+	randBytes := make([]byte, 4)
+	if _, err := rand.Read(randBytes); err != nil {
+		t.Fatalf("postgres.TablePrefix: generate prefix: %v", err)
+	}
+	prefix := "t" + hex.EncodeToString(randBytes) + "_"
+
+	t.Cleanup(func() {
+		if _, err := DropTablesWithPrefix(context.Background(), pool, prefix); err != nil {
+			t.Errorf("postgres.TablePrefix: cleanup failed: %v", err)
+		}
+	})
+	return prefix
+}
+
+// DropTablesWithPrefix drops every table in the pool's search_path schemas
+// whose name starts with prefix, in a single DROP TABLE ... CASCADE statement,
+// and returns how many were dropped. It is the cleanup behind TablePrefix, and
+// can also clear tables left behind by interrupted runs.
+//
+// The prefix is matched literally and must be non-empty (ErrEmptyPrefix).
+func DropTablesWithPrefix(ctx context.Context, pool *pgxpool.Pool, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, ErrEmptyPrefix
+	}
+
+	rows, err := pool.Query(ctx, `
+        SELECT schemaname, tablename FROM pg_tables
+        WHERE schemaname = ANY(current_schemas(false))
+        AND tablename LIKE $1
+        ORDER BY schemaname, tablename
+    `, likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return 0, fmt.Errorf("list tables: %w", err)
+	}
+
+	var schema, table string
+	var quoted []string
+	_, err = pgx.ForEachRow(rows, []any{&schema, &table}, func() error {
+		quoted = append(quoted, pgx.Identifier{schema, table}.Sanitize())
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list tables: %w", err)
+	}
+	if len(quoted) == 0 {
+		return 0, nil
+	}
+
+	if _, err := pool.Exec(ctx, "DROP TABLE IF EXISTS "+strings.Join(quoted, ", ")+" CASCADE"); err != nil {
+		return 0, fmt.Errorf("drop tables: %w", err)
+	}
+	return len(quoted), nil
+}
@@ -0,0 +1,48 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestTablePrefix(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t)
+
+	if _, err := pool.Exec(ctx, "CREATE TABLE shared_settings (key TEXT PRIMARY KEY)"); err != nil {
+		t.Fatalf("failed to create shared table: %v", err)
+	}
+
+	var prefix string
+	t.Run("test using prefixed tables", func(t *testing.T) {
+		prefix = postgres.TablePrefix(t, pool)
+
+		_, err := pool.Exec(ctx, `
+			CREATE TABLE `+prefix+`users (id SERIAL PRIMARY KEY);
+			CREATE TABLE `+prefix+`orders (id SERIAL PRIMARY KEY, user_id INT REFERENCES `+prefix+`users (id));
+		`)
+		if err != nil {
+			t.Fatalf("failed to create prefixed tables: %v", err)
+		}
+	})
+
+	tables, err := postgres.UserTables(ctx, pool)
+	if err != nil {
+		t.Fatalf("UserTables failed: %v", err)
+	}
+	if len(tables) != 1 || tables[0] != `"public"."shared_settings"` {
+		t.Errorf("expected only the shared table to remain after %s* tables were dropped, got %v", prefix, tables)
+	}
+}
+
+func TestDropTablesWithPrefixEmpty(t *testing.T) {
+	var pool *pgxpool.Pool // Not used: the prefix is checked first
+
+	if _, err := postgres.DropTablesWithPrefix(context.Background(), pool, ""); !errors.Is(err, postgres.ErrEmptyPrefix) {
+		t.Errorf("expected ErrEmptyPrefix, got %v", err)
+	}
+}
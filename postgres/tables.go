@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationTables are the bookkeeping tables of the supported migration tools
+// (tern, goose, golang-migrate). Reset functions leave them alone so the
+// database still reports its migrations as applied.
+var migrationTables = []string{"schema_version", "goose_db_version", "schema_migrations"}
+
+// UserTables returns the tables in the connection's search path schemas
+// ("public", unless the search path was changed), except the migration tools'
+// bookkeeping tables: tern's schema_version, goose's goose_db_version and
+// golang-migrate's schema_migrations, and any tables named in exclude (plain
+// or schema-qualified, e.g. the table set with testdb.WithMigrationTable).
+// Tables are sorted by schema and name.
+//
+// Names are schema-qualified and quoted with pgx.Identifier, so they can be
+// interpolated into statements such as TRUNCATE as is.
+//
+// Example:
+//
+//	tables, err := postgres.UserTables(ctx, pool, db.Config().MigrationTable)
+//	require.NoError(t, err)
+//
+//	// tables: ["public"."orders" "public"."users"]
+//	_, err = pool.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" CASCADE")
+func UserTables(ctx context.Context, pool *pgxpool.Pool, exclude ...string) ([]string, error) {
+	names := slices.Clone(migrationTables)
+	var qualified []string
+	for _, table := range exclude {
+		switch {
+		case table == "":
+		case strings.Contains(table, "."):
+			qualified = append(qualified, table)
+		default:
+			names = append(names, table)
+		}
+	}
+
+	rows, err := pool.Query(ctx, `
+        SELECT schemaname, tablename FROM pg_tables
+        WHERE schemaname = ANY(current_schemas(false))
+        AND tablename <> ALL($1)
+        AND schemaname || '.' || tablename <> ALL($2)
+        ORDER BY schemaname, tablename
+    `, names, qualified)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+
+	var schema, table string
+	var tables []string
+	_, err = pgx.ForEachRow(rows, []any{&schema, &table}, func() error {
+		tables = append(tables, pgx.Identifier{schema, table}.Sanitize())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	return tables, nil
+}
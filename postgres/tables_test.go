@@ -0,0 +1,57 @@
+package postgres_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestUserTables(t *testing.T) {
+	ctx := context.Background()
+	pool := postgres.Setup(t,
+		testdb.WithMigrations("../testdata/postgres/migrations_goose"),
+		testdb.WithMigrationTool(testdb.MigrationToolGoose))
+
+	if _, err := pool.Exec(ctx, `CREATE TABLE "Order Items" (id SERIAL PRIMARY KEY)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	tables, err := postgres.UserTables(ctx, pool)
+	if err != nil {
+		t.Fatalf("UserTables failed: %v", err)
+	}
+
+	want := []string{`"public"."Order Items"`, `"public"."products"`}
+	if !slices.Equal(tables, want) {
+		t.Errorf("expected %v, got %v", want, tables)
+	}
+}
+
+func TestUserTablesExcludesMigrationTable(t *testing.T) {
+	ctx := context.Background()
+	db := postgres.New(t, &postgres.PoolInitializer{},
+		testdb.WithMigrations("../testdata/postgres/migrations_goose"),
+		testdb.WithMigrationTool(testdb.MigrationToolGoose),
+		testdb.WithMigrationTable("app_migrations"))
+	pool := db.Entity().(*pgxpool.Pool)
+
+	tables, err := postgres.UserTables(ctx, pool, db.Config().MigrationTable)
+	if err != nil {
+		t.Fatalf("UserTables failed: %v", err)
+	}
+	if slices.Contains(tables, `"public"."app_migrations"`) {
+		t.Errorf("expected the custom migration table to be excluded, got %v", tables)
+	}
+
+	tables, err = postgres.UserTables(ctx, pool, "public.app_migrations")
+	if err != nil {
+		t.Fatalf("UserTables failed: %v", err)
+	}
+	if slices.Contains(tables, `"public"."app_migrations"`) {
+		t.Errorf("expected the schema-qualified migration table to be excluded, got %v", tables)
+	}
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bashhack/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrEmptyTemplateName is returned by PrewarmTemplate when called with an
+// empty template name.
+var ErrEmptyTemplateName = errors.New("template name cannot be empty")
+
+// PrewarmTemplate builds a template database for testdb.WithTemplate: it
+// creates templateName, runs the migrations configured by opts against it,
+// and marks it as a template (ALTER DATABASE ... WITH is_template = true) so
+// it can't be dropped by accident. Tests created with
+// testdb.WithTemplate(templateName) then clone it instead of migrating a new
+// database each time.
+//
+// Call it once before the tests run, from TestMain or a CI setup step. An
+// existing database with the same name is dropped and rebuilt, so the
+// template always reflects the current migrations. Roles configured with
+// testdb.WithRoles are created and left in place, since objects in the
+// template may reference them.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//	    err := postgres.PrewarmTemplate(context.Background(), adminDSN, "app_template",
+//	        testdb.WithMigrations("./migrations"),
+//	        testdb.WithMigrationTool(testdb.MigrationToolGoose))
+//	    if err != nil {
+//	        log.Fatalf("build template: %v", err)
+//	    }
+//	    os.Exit(m.Run())
+//	}
+//
+//	func TestUsers(t *testing.T) {
+//	    pool := postgres.Setup(t, testdb.WithTemplate("app_template"))
+//	    // ...
+//	}
+func PrewarmTemplate(ctx context.Context, adminDSN, templateName string, opts ...testdb.Option) error {
+	if templateName == "" {
+		return ErrEmptyTemplateName
+	}
+
+	cfg := testdb.DefaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.Isolation == testdb.IsolationSchema {
+		return testdb.ErrTemplateWithSchemaIsolation
+	}
+	cfg.AdminDSNOverride = adminDSN
+	if err := testdb.CheckProductionGuard(cfg, adminDSN); err != nil {
+		return err
+	}
+	cfg.Template = "" // The template itself starts from the server's default template
+	cfg.SchemaFallback = false
+
+	provider := &PostgresProvider{}
+	if err := provider.Initialize(ctx, cfg); err != nil {
+		return err
+	}
+	defer func() { _ = provider.Cleanup(ctx) }()
+
+	if err := provider.dropTemplate(ctx, templateName); err != nil {
+		return err
+	}
+
+	if err := provider.CreateDatabase(ctx, templateName); err != nil {
+		return err
+	}
+	provider.createdRoles = nil // Keep the roles, even if the template is dropped on failure
+
+	if err := provider.buildTemplate(ctx, templateName, cfg, opts); err != nil {
+		_ = provider.DropDatabase(context.WithoutCancel(ctx), templateName) // Best effort cleanup
+		return err
+	}
+
+	return nil
+}
+
+// buildTemplate migrates the newly created template database and marks it as
+// a template.
+func (p *PostgresProvider) buildTemplate(ctx context.Context, name string, cfg testdb.Config, opts []testdb.Option) error {
+	if cfg.MigrationDir != "" || len(cfg.MigrationDirsByDriver) > 0 {
+		dsn, err := p.BuildDSN(name)
+		if err != nil {
+			return fmt.Errorf("build template DSN: %w", err)
+		}
+		if err := testdb.MigrateDatabase(name, dsn, opts...); err != nil {
+			return err
+		}
+	}
+
+	sql := fmt.Sprintf("ALTER DATABASE %s WITH is_template = true", pgx.Identifier{name}.Sanitize())
+	if _, err := p.admin.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("mark database as template: %w", err)
+	}
+	return nil
+}
+
+// dropTemplate drops the named template database, if it exists, so
+// PrewarmTemplate can rebuild it. Template databases can't be dropped, so it
+// is unmarked first.
+func (p *PostgresProvider) dropTemplate(ctx context.Context, name string) error {
+	var exists bool
+	if err := p.admin.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+		return fmt.Errorf("check for existing template: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	sql := fmt.Sprintf("ALTER DATABASE %s WITH is_template = false", pgx.Identifier{name}.Sanitize())
+	if _, err := p.admin.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("unmark existing template: %w", err)
+	}
+	if err := p.TerminateConnections(ctx, name); err != nil {
+		return err
+	}
+	if err := p.dropDatabase(ctx, name); err != nil {
+		return fmt.Errorf("drop existing template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bashhack/testdb"
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestPrewarmTemplateEmptyName(t *testing.T) {
+	err := postgres.PrewarmTemplate(context.Background(), testAdminDSN(), "")
+	if !errors.Is(err, postgres.ErrEmptyTemplateName) {
+		t.Fatalf("expected ErrEmptyTemplateName, got %v", err)
+	}
+}
+
+func TestPrewarmTemplate(t *testing.T) {
+	ctx := context.Background()
+	templateName := fmt.Sprintf("template_%d", time.Now().UnixNano())
+	migrations := []testdb.Option{
+		testdb.WithMigrations("../testdata/postgres/migrations_migrate"),
+		testdb.WithMigrationTool(testdb.MigrationToolMigrate),
+		testdb.WithMigrateInProcess(),
+	}
+
+	if err := postgres.PrewarmTemplate(ctx, testAdminDSN(), templateName, migrations...); err != nil {
+		t.Fatalf("PrewarmTemplate failed: %v", err)
+	}
+	t.Cleanup(func() { dropTemplate(t, templateName) })
+
+	// Rebuilding an existing template replaces it
+	if err := postgres.PrewarmTemplate(ctx, testAdminDSN(), templateName, migrations...); err != nil {
+		t.Fatalf("rebuilding the template failed: %v", err)
+	}
+
+	admin, err := pgx.Connect(ctx, testAdminDSN())
+	if err != nil {
+		t.Fatalf("failed to connect to admin database: %v", err)
+	}
+	defer func() { _ = admin.Close(ctx) }()
+
+	var isTemplate bool
+	if err := admin.QueryRow(ctx, "SELECT datistemplate FROM pg_database WHERE datname = $1", templateName).Scan(&isTemplate); err != nil {
+		t.Fatalf("failed to query template: %v", err)
+	}
+	if !isTemplate {
+		t.Error("expected the database to be marked as a template")
+	}
+
+	// Test databases cloned from the template have its tables without migrating
+	pool := postgres.Setup(t, testdb.WithTemplate(templateName))
+
+	if _, err := pool.Exec(ctx, "INSERT INTO test_table (name) VALUES ('cloned')"); err != nil {
+		t.Fatalf("expected test_table to be cloned from the template: %v", err)
+	}
+}
+
+// dropTemplate unmarks and drops a template database created by a test.
+func dropTemplate(t *testing.T, name string) {
+	t.Helper()
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, testAdminDSN())
+	if err != nil {
+		t.Errorf("failed to connect to admin database: %v", err)
+		return
+	}
+	defer pool.Close()
+
+	quoted := pgx.Identifier{name}.Sanitize()
+	if _, err := pool.Exec(ctx, "ALTER DATABASE "+quoted+" WITH is_template = false"); err != nil {
+		t.Errorf("failed to unmark template: %v", err)
+		return
+	}
+	if _, err := pool.Exec(ctx, "DROP DATABASE IF EXISTS "+quoted); err != nil {
+		t.Errorf("failed to drop template: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TxOption configures the transaction started by SetupTx.
+type TxOption func(*txConfig)
+
+// txConfig holds the settings applied by TxOptions.
+type txConfig struct {
+	txOptions pgx.TxOptions
+}
+
+// WithTxOptions sets the options (isolation level, access mode, deferrable)
+// of the transaction started by SetupTx.
+//
+// Example:
+//
+//	tx := postgres.SetupTx(t, dsn, postgres.WithTxOptions(pgx.TxOptions{IsoLevel: pgx.Serializable}))
+func WithTxOptions(opts pgx.TxOptions) TxOption {
+	return func(c *txConfig) {
+		c.txOptions = opts
+	}
+}
+
+var (
+	// sharedTxPoolsMu guards sharedTxPools.
+	sharedTxPoolsMu sync.Mutex
+
+	// sharedTxPools holds the process-wide pools used by SetupTx, keyed by DSN.
+	sharedTxPools = map[string]*pgxpool.Pool{}
+)
+
+// sharedTxPool returns the shared pool for dsn, creating it on first use.
+// The pool size follows pgx's defaults unless the DSN sets pool_max_conns.
+func sharedTxPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	sharedTxPoolsMu.Lock()
+	defer sharedTxPoolsMu.Unlock()
+
+	if pool, ok := sharedTxPools[dsn]; ok {
+		return pool, nil
+	}
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("create shared pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("connect to shared database: %w", err)
+	}
+
+	sharedTxPools[dsn] = pool
+	return pool, nil
+}
+
+// SetupTx begins a transaction against an existing, already migrated database
+// shared by many tests, and registers cleanup that rolls it back. Nothing is
+// created or dropped, so this is the fastest isolation mode, at the cost of
+// everything a test does having to fit in one transaction.
+//
+// Connections come from a pool shared by all SetupTx calls with the same DSN.
+// Each test holds one connection until it finishes, so parallel tests wait for
+// a free connection once the pool is exhausted (set pool_max_conns in the DSN
+// to change its size). See CloseSharedTxPools for closing the pools.
+//
+// Transaction caveats:
+//   - Code under test must not Commit or Rollback the transaction. Nested
+//     tx.Begin() calls create savepoints and are fine. A transaction that was
+//     closed by the test fails the test at cleanup, since its changes may have
+//     been committed to the shared database.
+//   - Statements that can't run in a transaction block fail, e.g. CREATE
+//     DATABASE, CREATE INDEX CONCURRENTLY, VACUUM.
+//   - Other DDL works and is rolled back, but takes locks held until the end of
+//     the test, which can block parallel tests touching the same tables.
+//   - Sequence values (SERIAL, nextval) are not rolled back, so don't assert on
+//     generated IDs.
+//   - now() returns the transaction start time for the whole test.
+//   - Parallel tests can't see each other's uncommitted rows, but can still
+//     conflict on unique constraints and row locks.
+//
+// Calls t.Fatal() on any error.
+//
+// Example:
+//
+//	func TestCreateUser(t *testing.T) {
+//	    tx := postgres.SetupTx(t, os.Getenv("SHARED_TEST_DATABASE_URL"))
+//
+//	    _, err := tx.Exec(ctx, "INSERT INTO users (email) VALUES ($1)", "test@example.com")
+//	    require.NoError(t, err)
+//	    // Rolled back automatically when the test ends
+//	}
+func SetupTx(t testing.TB, sharedDSN string, opts ...TxOption) pgx.Tx {
+	t.Helper()
+
+	if sharedDSN == "" {
+		t.Fatalf("postgres.SetupTx: shared DSN cannot be empty")
+	}
+
+	var cfg txConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx := context.Background()
+	pool, err := sharedTxPool(ctx, sharedDSN)
+	if err != nil {
+		t.Fatalf("postgres.SetupTx: %v", err)
+	}
+
+	tx, err := pool.BeginTx(ctx, cfg.txOptions)
+	if err != nil {
+		t.Fatalf("postgres.SetupTx: begin transaction: %v", err)
+	}
+
+	t.Cleanup(func() {
+		err := tx.Rollback(context.Background())
+		if errors.Is(err, pgx.ErrTxClosed) {
+			t.Errorf("postgres.SetupTx: transaction was committed or rolled back by the test; its changes may remain in the shared database")
+		} else if err != nil {
+			t.Errorf("postgres.SetupTx: rollback failed: %v", err)
+		}
+	})
+
+	return tx
+}
+
+// CloseSharedTxPools closes the pools created by SetupTx. Call it from
+// TestMain after m.Run() to release the connections before the test binary
+// exits; later SetupTx calls open a new pool.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//	    code := m.Run()
+//	    postgres.CloseSharedTxPools()
+//	    os.Exit(code)
+//	}
+func CloseSharedTxPools() {
+	sharedTxPoolsMu.Lock()
+	defer sharedTxPoolsMu.Unlock()
+
+	for dsn, pool := range sharedTxPools {
+		pool.Close()
+		delete(sharedTxPools, dsn)
+	}
+}
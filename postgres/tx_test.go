@@ -0,0 +1,101 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bashhack/testdb/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestSetupTx(t *testing.T) {
+	ctx := context.Background()
+
+	// A migrated database shared by the transactional tests below
+	shared := postgres.New(t, &postgres.PoolInitializer{})
+	t.Cleanup(postgres.CloseSharedTxPools) // Runs before the shared database is dropped
+	pool := shared.Entity().(*pgxpool.Pool)
+	if _, err := pool.Exec(ctx, "CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT UNIQUE)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	t.Run("changes are rolled back", func(t *testing.T) {
+		tx := postgres.SetupTx(t, shared.DSN())
+		if _, err := tx.Exec(ctx, "INSERT INTO users (email) VALUES ('tx@example.com')"); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+
+		var count int
+		if err := tx.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&count); err != nil {
+			t.Fatalf("count failed: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("expected the insert to be visible in the transaction, got %d rows", count)
+		}
+	})
+
+	var count int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&count); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected the insert to be rolled back, got %d rows", count)
+	}
+
+	t.Run("tx options applied", func(t *testing.T) {
+		tx := postgres.SetupTx(t, shared.DSN(), postgres.WithTxOptions(pgx.TxOptions{IsoLevel: pgx.Serializable}))
+
+		var level string
+		if err := tx.QueryRow(ctx, "SHOW transaction_isolation").Scan(&level); err != nil {
+			t.Fatalf("failed to query isolation level: %v", err)
+		}
+		if level != "serializable" {
+			t.Errorf("expected serializable isolation, got %s", level)
+		}
+	})
+
+	t.Run("committed transaction fails cleanup", func(t *testing.T) {
+		spy := &errorSpyTB{spyTB: &spyTB{TB: t}}
+		tx := postgres.SetupTx(spy, shared.DSN())
+		if err := tx.Rollback(ctx); err != nil {
+			t.Fatalf("rollback failed: %v", err)
+		}
+
+		spy.runCleanups()
+		if !spy.errored {
+			t.Error("expected cleanup to report the closed transaction")
+		}
+	})
+}
+
+func TestSetupTxEmptyDSN(t *testing.T) {
+	spy := &spyTB{TB: t}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(fatalPanic); !ok {
+				panic(r) // Re-panic if it's not our sentinel
+			}
+		}
+
+		if !strings.Contains(spy.fatalMessage, "postgres.SetupTx") {
+			t.Errorf("Expected error message to contain 'postgres.SetupTx', got: %s", spy.fatalMessage)
+		}
+	}()
+
+	postgres.SetupTx(spy, "")
+}
+
+// errorSpyTB is a spyTB that also records Errorf calls instead of failing the test.
+type errorSpyTB struct {
+	*spyTB
+	errored bool
+}
+
+func (s *errorSpyTB) Errorf(format string, args ...any) {
+	s.errored = true
+	s.logMessages = append(s.logMessages, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,3 @@
+ALTER TABLE accounts ADD COLUMN first_name TEXT;
+
+UPDATE accounts SET first_name = split_part(full_name, ' ', 1);
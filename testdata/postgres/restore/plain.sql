@@ -0,0 +1,6 @@
+CREATE TABLE products (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+INSERT INTO products (name) VALUES ('widget'), ('gadget'), ('gizmo');
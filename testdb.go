@@ -4,6 +4,8 @@
 //
 // Supported databases:
 //   - PostgreSQL (github.com/bashhack/testdb/postgres)
+//   - CockroachDB (github.com/bashhack/testdb/cockroach)
+//   - MongoDB (github.com/bashhack/testdb/mongo)
 //
 // Basic usage with PostgreSQL (automatic cleanup via postgres.Setup):
 //
@@ -47,7 +49,19 @@ package testdb
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
 )
 
 // TestDatabase represents an isolated test database instance.
@@ -58,18 +72,32 @@ import (
 //   - Cleanup and resource disposal
 type TestDatabase struct {
 	// name is the unique identifier for this test database.
+	// Under schema isolation this is the schema name.
 	// Example: "test_1699564231_a1b2c3d4"
 	name string
 
+	// testName is the name of the test that created the database, kept to
+	// generate a new name if the first one is already taken.
+	testName string
+
+	// isolation is how this test database is isolated from other tests.
+	isolation Isolation
+
 	// dsn is the connection string for this specific test database.
 	// This is always available and can be used with any database client.
 	dsn string
 
+	// replicaDSN is the connection string returned by ReplicaDSN.
+	replicaDSN string
+
 	// config holds the configuration used to create this database.
 	config Config
 
-	// cleanup is the function called by Close() to clean up resources.
+	// cleanup is the function called by Close() to clean up resources. It is
+	// set to nil once called; closeMu guards it, so concurrent Close calls
+	// drop the database once.
 	cleanup func() error
+	closeMu sync.Mutex
 
 	// t is the testing context for logging.
 	t testingHelper
@@ -78,25 +106,138 @@ type TestDatabase struct {
 	// Type assert this to your expected type (e.g., *pgxpool.Pool, *sqlx.DB).
 	entity any
 
+	// initializer created entity, and is kept so Reinitialize can run it again.
+	initializer DBInitializer
+
 	// provider is the database-specific implementation.
 	provider Provider
+
+	// migrationDuration is how long the last successful RunMigrations took.
+	migrationDuration time.Duration
+
+	// migrated is set once RunMigrations has succeeded, so cleanup knows
+	// there are migrations to roll back (see WithVerifyDownMigrations).
+	migrated bool
+
+	// serverVersion is the provider-reported server version (0 if unknown).
+	serverVersion int
+
+	// create creates the database on first use with WithLazyCreate (nil
+	// otherwise). createOnce runs it once, recording its error in createErr;
+	// tb fails the test if it returns an error.
+	create     func() error
+	createOnce sync.Once
+	createErr  error
+	created    atomic.Bool
+	tb         testing.TB
 }
 
-// Name returns the unique database name for this test database.
+// Name returns the unique database name for this test database. With
+// WithLazyCreate, the name changes when the database is created if the
+// original name turns out to be taken (see ErrDatabaseExists).
 func (td *TestDatabase) Name() string {
 	return td.name
 }
 
 // DSN returns the connection string for this test database.
 func (td *TestDatabase) DSN() string {
+	td.ensureCreated()
 	return td.dsn
 }
 
+// ReplicaDSN returns a connection string for a read replica of this test
+// database, for applications configured with separate primary and replica
+// connection strings. There is only one test database, so the replica is the
+// same database as DSN; with WithReadOnlyReplica its sessions are read-only,
+// so writes sent to the replica fail as they would against a real one.
+//
+// Example:
+//
+//	t.Setenv("DATABASE_URL", db.DSN())
+//	t.Setenv("DATABASE_REPLICA_URL", db.ReplicaDSN())
+func (td *TestDatabase) ReplicaDSN() string {
+	td.ensureCreated()
+	return td.replicaDSN
+}
+
+// RoleDSN returns the connection string for this test database with user and
+// password replaced by role and password, for connecting as a role other than
+// the admin user, e.g. one limited with WithRoleConnLimit. An empty password
+// leaves the password out. It fails if the DSN isn't a URL.
+//
+// Example:
+//
+//	dsn, err := db.RoleDSN("app_user", "app")
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	pool, err := pgxpool.New(ctx, dsn)
+func (td *TestDatabase) RoleDSN(role, password string) (string, error) {
+	td.ensureCreated()
+	u, err := url.Parse(td.dsn)
+	if err != nil || u.Scheme == "" {
+		return "", &Error{
+			Op:       "RoleDSN",
+			Database: td.name,
+			Err:      errors.New("test DSN is not a URL"),
+		}
+	}
+	u.User = url.UserPassword(role, password)
+	if password == "" {
+		u.User = url.User(role)
+	}
+	return u.String(), nil
+}
+
+// Created reports whether the database has been created. It is always true
+// unless WithLazyCreate is set, in which case the database is created by the
+// first call to a method that needs it, such as Entity or DSN. Cleanup code
+// can check it to avoid creating a database just to drop it.
+func (td *TestDatabase) Created() bool {
+	return td.create == nil || td.created.Load()
+}
+
+// ensureCreated creates a WithLazyCreate database on first use, failing the
+// test if that fails. Concurrent callers wait for the first to finish.
+func (td *TestDatabase) ensureCreated() {
+	if td.create == nil {
+		return
+	}
+	td.createOnce.Do(func() { td.createErr = td.create() })
+	if td.createErr != nil {
+		td.tb.Helper()
+		td.tb.Fatalf("testdb: create %s: %v", td.name, td.createErr)
+	}
+}
+
 // Config returns the configuration used to create this database.
 func (td *TestDatabase) Config() Config {
 	return td.config
 }
 
+// Isolation returns how this test database is isolated from other tests.
+// This is IsolationDatabase unless schema isolation was requested (see
+// WithIsolation) or the provider fell back to it (see WithSchemaFallback).
+func (td *TestDatabase) Isolation() Isolation {
+	td.ensureCreated()
+	return td.isolation
+}
+
+// ServerVersion returns the database server version reported by the provider
+// when this test database was created, or 0 if the provider doesn't report one
+// (see ServerVersionReporter). For PostgreSQL this is server_version_num, e.g.
+// 160002 for 16.2.
+//
+// Example:
+//
+//	if db.ServerVersion() < 140000 {
+//	    t.Skip("requires PostgreSQL 14+")
+//	}
+func (td *TestDatabase) ServerVersion() int {
+	td.ensureCreated()
+	return td.serverVersion
+}
+
 // Provider defines database-specific operations that must be implemented
 // for each supported database system (PostgreSQL, MySQL, SQLite, MongoDB).
 //
@@ -129,6 +270,36 @@ type Provider interface {
 	Cleanup(ctx context.Context) error
 }
 
+// IsolationReporter is an optional interface for providers that can isolate a
+// test database by means other than a dedicated database (for example, a
+// schema requested with WithIsolation, or falling back to a schema when
+// CREATE DATABASE is not permitted).
+//
+// New() uses it after CreateDatabase to record the isolation mode on the
+// TestDatabase. Providers that don't implement it are assumed to always use
+// IsolationDatabase.
+type IsolationReporter interface {
+	// IsolationFor returns the isolation mode used for the named test database.
+	IsolationFor(name string) Isolation
+}
+
+// AdminExecer is an optional interface for providers that can run statements
+// on their admin connection. New() passes the provider to the hooks set with
+// WithBeforeCreate and WithAfterCreate through it.
+type AdminExecer interface {
+	// ExecAdmin runs sql with args on the admin connection.
+	ExecAdmin(ctx context.Context, sql string, args ...any) error
+}
+
+// ServerVersionReporter is an optional interface for providers that know the
+// version of the server they manage test databases on. New() records it on the
+// TestDatabase (see TestDatabase.ServerVersion).
+type ServerVersionReporter interface {
+	// ResolvedServerVersion returns the server version as a single comparable
+	// number (e.g. PostgreSQL's server_version_num), or 0 if unknown.
+	ResolvedServerVersion() int
+}
+
 // DBInitializer defines the interface for custom database initialization in tests.
 //
 // # When You Need a Custom Initializer
@@ -209,6 +380,57 @@ type DBInitializer interface {
 	InitializeTestDatabase(ctx context.Context, dsn string) (any, error)
 }
 
+// AutoMigrator is implemented by entities that migrate their own schema, such
+// as an ORM creating tables from its models. With WithAutoMigrate,
+// postgres.New calls AutoMigrate once the entity is initialized (see
+// TestDatabase.RunAutoMigrate).
+//
+// ORM handles rarely implement it directly, since their migration methods take
+// the models as arguments; return a small adapter from the initializer instead:
+//
+//	type GormEntity struct{ *gorm.DB }
+//
+//	func (e *GormEntity) AutoMigrate() error {
+//	    return e.DB.AutoMigrate(&User{}, &Order{})
+//	}
+//
+//	func (i *GormInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
+//	    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return &GormEntity{DB: db}, nil
+//	}
+type AutoMigrator interface {
+	// AutoMigrate creates or updates the schema of the test database.
+	AutoMigrate() error
+}
+
+// configContextKey is the context key under which New passes the Config to
+// initializers.
+type configContextKey struct{}
+
+// ConfigFromContext returns the Config of the test database being initialized.
+// New and Reinitialize attach it to the context passed to
+// DBInitializer.InitializeTestDatabase, so initializers can honor options such
+// as WithConnInitSQL. The second result is false if ctx carries no Config.
+//
+// Example:
+//
+//	func (i *MyInitializer) InitializeTestDatabase(ctx context.Context, dsn string) (any, error) {
+//	    cfg, _ := testdb.ConfigFromContext(ctx)
+//	    // apply cfg.ConnInitSQL to each new connection...
+//	}
+func ConfigFromContext(ctx context.Context) (Config, bool) {
+	cfg, ok := ctx.Value(configContextKey{}).(Config)
+	return cfg, ok
+}
+
+// contextWithConfig returns a copy of ctx carrying cfg for ConfigFromContext.
+func contextWithConfig(ctx context.Context, cfg Config) context.Context {
+	return context.WithValue(ctx, configContextKey{}, cfg)
+}
+
 // testingHelper is a minimal interface that both *testing.T and *testing.B satisfy.
 // This allows TestDatabase to work with both regular tests and benchmarks.
 type testingHelper interface {
@@ -250,6 +472,80 @@ type testingHelper interface {
 //	pool := db.Entity().(*pgxpool.Pool)
 func New(t testing.TB, provider Provider, initializer DBInitializer, opts ...Option) (*TestDatabase, error) {
 	t.Helper()
+	return NewContext(context.Background(), t, provider, initializer, opts...)
+}
+
+// NewWithCleanup is like New but also returns a cleanup function, for test
+// harnesses that prefer an explicit cleanup call to defer db.Close(). The
+// cleanup function closes the entity with CloseEntity (so a *pgxpool.Pool or
+// *sql.DB is closed), as the postgres package's helpers do, then
+// closes the database, reporting a failure with t.Errorf. Calls after the
+// first do nothing.
+//
+// Example:
+//
+//	db, cleanup, err := testdb.NewWithCleanup(t, provider, initializer)
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	t.Cleanup(cleanup)
+func NewWithCleanup(t testing.TB, provider Provider, initializer DBInitializer, opts ...Option) (*TestDatabase, func(), error) {
+	t.Helper()
+
+	db, err := New(t, provider, initializer, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		// A WithLazyCreate database that was never used has no entity
+		if db.Created() {
+			if err := CloseEntity(db.Entity()); err != nil {
+				t.Logf("Warning: failed to close entity: %v", err)
+			}
+		}
+
+		if err := db.Close(); err != nil {
+			t.Errorf("testdb cleanup failed: %v", err)
+		}
+	}
+	return db, sync.OnceFunc(cleanup), nil
+}
+
+// Must returns db, or panics with err if it is non-nil. It wraps calls to New
+// (and its variants returning a database and an error) in helpers that can't
+// call t.Fatal, in the style of template.Must.
+//
+// Example:
+//
+//	db := testdb.Must(testdb.New(t, provider, initializer))
+//	defer db.Close()
+func Must(db *TestDatabase, err error) *TestDatabase {
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// NewContext is like New but uses ctx for creating the test database and
+// initializing its entity, so a test can bound setup time or propagate
+// cancellation. If ctx is canceled during setup, NewContext returns an error.
+//
+// Cleanup (Close) is not canceled along with ctx: the database is still
+// dropped after ctx is done.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//
+//	db, err := testdb.NewContext(ctx, t, provider, initializer)
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer db.Close()
+func NewContext(ctx context.Context, t testing.TB, provider Provider, initializer DBInitializer, opts ...Option) (*TestDatabase, error) {
+	t.Helper()
 
 	if provider == nil {
 		return nil, &Error{
@@ -270,15 +566,44 @@ func New(t testing.TB, provider Provider, initializer DBInitializer, opts ...Opt
 		}
 	}
 
-	ctx := context.Background()
-	if err := provider.Initialize(ctx, cfg); err != nil {
-		return nil, &Error{
-			Op:  "provider.Initialize",
-			Err: err,
+	if len(cfg.MigrationFiles) > 0 {
+		dir := t.TempDir()
+		if err := writeMigrationFiles(dir, cfg.MigrationFiles); err != nil {
+			return nil, &Error{
+				Op:  "writeMigrationFiles",
+				Err: err,
+			}
+		}
+		cfg.MigrationDir = dir
+	}
+
+	if cfg.AdminDSNResolver != nil {
+		adminDSN, err := cfg.AdminDSNResolver(ctx)
+		if err != nil {
+			return nil, &Error{
+				Op:  "AdminDSNResolver",
+				Err: err,
+			}
+		}
+		if adminDSN == "" {
+			return nil, &Error{
+				Op:  "AdminDSNResolver",
+				Err: ErrEmptyAdminDSN,
+			}
 		}
+		cfg.AdminDSNOverride = adminDSN
 	}
 
-	dbName, err := generateDatabaseName(cfg.DBPrefix)
+	if cfg.AppNameWithTest {
+		if cfg.AppName == "" {
+			cfg.AppName = t.Name()
+		} else {
+			cfg.AppName += "/" + t.Name()
+		}
+		cfg.AppNameWithTest = false
+	}
+
+	dbName, err := databaseName(cfg, t.Name())
 	if err != nil {
 		return nil, &Error{
 			Op:  "generateDatabaseName",
@@ -286,75 +611,324 @@ func New(t testing.TB, provider Provider, initializer DBInitializer, opts ...Opt
 		}
 	}
 
-	if cfg.Verbose {
-		t.Logf("testdb: creating database %s", dbName)
+	td := &TestDatabase{
+		name:        dbName,
+		testName:    t.Name(),
+		config:      cfg,
+		t:           t,
+		provider:    provider,
+		initializer: initializer,
 	}
 
-	if err := provider.CreateDatabase(ctx, dbName); err != nil {
-		return nil, &Error{
-			Op:  "provider.CreateDatabase",
+	if cfg.LazyCreate {
+		td.tb = t
+		td.create = func() error {
+			if err := td.createDatabase(context.WithoutCancel(ctx)); err != nil {
+				return err
+			}
+			td.created.Store(true)
+			return nil
+		}
+		return td, nil
+	}
+
+	if err := td.createDatabase(ctx); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// maxCreateAttempts is how many names NewContext tries before giving up when
+// the provider reports that the database already exists (ErrDatabaseExists).
+const maxCreateAttempts = 3
+
+// runCreateHook runs hook, if set, with provider's admin connection.
+func runCreateHook(ctx context.Context, provider Provider, hook CreateHook, dbName string) error {
+	if hook == nil {
+		return nil
+	}
+	admin, ok := provider.(AdminExecer)
+	if !ok {
+		return ErrAdminExecNotSupported
+	}
+	return hook(ctx, admin, dbName)
+}
+
+// createDatabase implements the part of NewContext that creates td's database
+// and initializes its entity: right away, or on first use with
+// WithLazyCreate. On failure, everything created so far is cleaned up.
+func (td *TestDatabase) createDatabase(ctx context.Context) error {
+	provider, initializer, dbName := td.provider, td.initializer, td.name
+
+	// Cleanup must still run after ctx is canceled or times out
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	var waitLog func(format string, args ...any)
+	if td.config.Verbose {
+		waitLog = td.t.Logf
+	}
+	release, err := acquireDatabaseSlot(ctx, td.config.MaxConcurrent, waitLog)
+	if err != nil {
+		return &Error{
+			Op:  "acquireDatabaseSlot",
+			Err: err,
+		}
+	}
+
+	// The slot is released by cleanup once the database exists, or here if
+	// New() fails before then.
+	slotHandedOff := false
+	defer func() {
+		if !slotHandedOff {
+			release()
+		}
+	}()
+
+	// Check an admin DSN set by the user (WithAdminDSN, WithAdminDSNResolver or
+	// the environment) before the provider connects to it
+	if adminDSN := ResolveAdminDSN(td.config, ""); adminDSN != "" {
+		if err := CheckProductionGuard(td.config, adminDSN); err != nil {
+			return &Error{
+				Op:  "CheckProductionGuard",
+				Err: err,
+			}
+		}
+	}
+
+	if err := provider.Initialize(ctx, td.config); err != nil {
+		return &Error{
+			Op:  "provider.Initialize",
 			Err: err,
 		}
 	}
 
+	// The provider may have resolved a DSN of its own, e.g. its default with
+	// the libpq environment variables applied. The built-in providers check it
+	// before connecting; this covers providers that don't.
+	if err := CheckProductionGuard(td.config, provider.ResolvedAdminDSN()); err != nil {
+		_ = provider.Cleanup(cleanupCtx) // Best effort cleanup
+		return &Error{
+			Op:  "CheckProductionGuard",
+			Err: err,
+		}
+	}
+
+	if len(td.config.MigrationDirsByDriver) > 0 {
+		dir, err := resolveMigrationDir(td.config, provider.ResolvedAdminDSN())
+		if err != nil {
+			_ = provider.Cleanup(cleanupCtx) // Best effort cleanup
+			return &Error{
+				Op:  "resolveMigrationDir",
+				Err: err,
+			}
+		}
+		td.config.MigrationDir = dir
+	}
+
+	if td.config.Verbose {
+		td.t.Logf("testdb: creating database %s", dbName)
+	}
+
+	// A generated name can collide with an existing database, e.g. with a
+	// NameFunc producing short names under heavy parallelism; pick another.
+	// BeforeCreate runs for every name tried.
+	for attempt := 1; ; attempt++ {
+		if err := runCreateHook(ctx, provider, td.config.BeforeCreate, dbName); err != nil {
+			_ = provider.Cleanup(cleanupCtx) // Best effort cleanup
+			return &Error{
+				Op:  "BeforeCreate",
+				Err: err,
+			}
+		}
+
+		err := provider.CreateDatabase(ctx, dbName)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrDatabaseExists) {
+			_ = provider.Cleanup(cleanupCtx) // Best effort cleanup
+			return &Error{
+				Op:  "provider.CreateDatabase",
+				Err: err,
+			}
+		}
+		if attempt == maxCreateAttempts {
+			_ = provider.Cleanup(cleanupCtx) // Best effort cleanup
+			return &Error{
+				Op:  "provider.CreateDatabase",
+				Err: fmt.Errorf("gave up after %d attempts: %w", attempt, err),
+			}
+		}
+
+		name, err := databaseName(td.config, td.testName)
+		if err != nil {
+			_ = provider.Cleanup(cleanupCtx) // Best effort cleanup
+			return &Error{
+				Op:  "generateDatabaseName",
+				Err: err,
+			}
+		}
+		td.logf("testdb: database %s already exists, retrying as %s", dbName, name)
+		dbName = name
+		td.name = name
+	}
+
+	isolation := IsolationDatabase
+	if reporter, ok := provider.(IsolationReporter); ok {
+		isolation = reporter.IsolationFor(dbName)
+	}
+
+	if td.config.Isolation == IsolationSchema && isolation != IsolationSchema {
+		_ = provider.DropDatabase(cleanupCtx, dbName) // Best effort cleanup
+		_ = provider.Cleanup(cleanupCtx)
+		return &Error{
+			Op:  "provider.CreateDatabase",
+			Err: fmt.Errorf("%w: requested %s isolation, got %s", ErrIsolationNotSupported, td.config.Isolation, isolation),
+		}
+	}
+
+	if td.config.SchemaFallback {
+		td.t.Logf("testdb: using %s isolation for %s", isolation, dbName)
+	}
+
+	if err := runCreateHook(ctx, provider, td.config.AfterCreate, dbName); err != nil {
+		_ = provider.DropDatabase(cleanupCtx, dbName) // Best effort cleanup
+		_ = provider.Cleanup(cleanupCtx)
+		return &Error{
+			Op:       "AfterCreate",
+			Database: dbName,
+			Err:      err,
+		}
+	}
+
 	testDSN, err := provider.BuildDSN(dbName)
 	if err != nil {
-		_ = provider.DropDatabase(ctx, dbName) // Best effort cleanup
-		return nil, &Error{
+		_ = provider.DropDatabase(cleanupCtx, dbName) // Best effort cleanup
+		_ = provider.Cleanup(cleanupCtx)
+		return &Error{
 			Op:  "provider.BuildDSN",
 			Err: err,
 		}
 	}
 
-	td := &TestDatabase{
-		name:     dbName,
-		config:   cfg,
-		dsn:      testDSN,
-		t:        t,
-		provider: provider,
+	replicaDSN := testDSN
+	if td.config.ReadOnlyReplica {
+		replicaDSN, err = withRuntimeParams(testDSN, [][2]string{{"default_transaction_read_only", "on"}})
+		if err != nil {
+			_ = provider.DropDatabase(cleanupCtx, dbName) // Best effort cleanup
+			_ = provider.Cleanup(cleanupCtx)
+			return &Error{
+				Op:  "testdb.New",
+				Err: fmt.Errorf("build replica DSN: %w", err),
+			}
+		}
 	}
 
-	td.cleanup = func() error {
-		if err := provider.TerminateConnections(ctx, dbName); err != nil {
+	td.isolation = isolation
+	td.dsn = testDSN
+	td.replicaDSN = replicaDSN
+	if reporter, ok := provider.(ServerVersionReporter); ok {
+		td.serverVersion = reporter.ResolvedServerVersion()
+	}
+
+	drop := func() error {
+		if err := provider.TerminateConnections(cleanupCtx, dbName); err != nil {
 			return &Error{
 				Op:  "provider.TerminateConnections",
 				Err: err,
 			}
 		}
 
-		if err := provider.DropDatabase(ctx, dbName); err != nil {
+		if err := provider.DropDatabase(cleanupCtx, dbName); err != nil {
 			return &Error{
 				Op:  "provider.DropDatabase",
 				Err: err,
 			}
 		}
 
-		if err := provider.Cleanup(ctx); err != nil {
+		if err := provider.Cleanup(cleanupCtx); err != nil {
 			return &Error{
 				Op:  "provider.Cleanup",
 				Err: err,
 			}
 		}
 
-		if cfg.Verbose {
-			t.Logf("testdb: dropped database %s", dbName)
+		if td.config.Verbose {
+			td.t.Logf("testdb: dropped database %s", dbName)
 		}
 		return nil
 	}
+	td.cleanup = func() error {
+		defer release()
+
+		// A failed down migration is reported, but the database is dropped
+		// regardless.
+		var downErr error
+		if td.config.VerifyDownMigrations && td.migrated {
+			downErr = td.rollbackAllMigrations("VerifyDownMigrations")
+		}
+
+		err := drop()
+		if downErr != nil {
+			return errors.Join(downErr, err)
+		}
+		return err
+	}
+	slotHandedOff = true
+
+	if td.config.MigrateBeforeInit && td.config.MigrationDir != "" {
+		if _, err := td.runMigrations("testdb.New"); err != nil {
+			_ = td.drop() // Best effort cleanup
+			return err
+		}
+	}
 
 	if initializer != nil {
-		entity, err := initializer.InitializeTestDatabase(ctx, td.dsn)
+		entity, err := td.initializeEntity(ctx)
 		if err != nil {
-			_ = td.Close() // Best effort cleanup
-			return nil, &Error{
-				Op:  "initializer.InitializeTestDatabase",
-				Err: err,
-			}
+			_ = td.drop() // Best effort cleanup
+			return withDatabase(err, dbName)
 		}
 		td.entity = entity
 	}
 
-	return td, nil
+	return nil
+}
+
+// initializeEntity runs the initializer against the test database and applies
+// the WithEntityWrapper function, if any, to its entity.
+func (td *TestDatabase) initializeEntity(ctx context.Context) (any, error) {
+	entity, err := td.initializer.InitializeTestDatabase(contextWithConfig(ctx, td.config), td.dsn)
+	if err != nil {
+		return nil, &Error{
+			Op:  "initializer.InitializeTestDatabase",
+			Err: err,
+		}
+	}
+
+	if td.config.EntityWrapper == nil {
+		return entity, nil
+	}
+
+	wrapped, err := td.config.EntityWrapper(entity)
+	if err != nil {
+		_ = CloseEntity(entity) // Best effort cleanup
+		return nil, &Error{
+			Op:  "EntityWrapper",
+			Err: err,
+		}
+	}
+	return wrapped, nil
+}
+
+// withDatabase records name as the Database of err if it is an *Error that
+// doesn't name one yet, and returns err.
+func withDatabase(err error, name string) error {
+	var tdbErr *Error
+	if errors.As(err, &tdbErr) && tdbErr.Database == "" {
+		tdbErr.Database = name
+	}
+	return err
 }
 
 // Entity returns the initialized database entity.
@@ -379,14 +953,114 @@ func New(t testing.TB, provider Provider, initializer DBInitializer, opts ...Opt
 // Note: Since you control the DBInitializer, direct assertions are usually safe.
 // Panics during test setup help catch initialization bugs early.
 func (td *TestDatabase) Entity() any {
+	td.ensureCreated()
 	return td.entity
 }
 
-// logf logs a message if verbose mode is enabled.
-func (td *TestDatabase) logf(format string, args ...any) {
-	if td.config.Verbose {
-		td.t.Logf(format, args...)
-	}
+// CloseEntity closes entity if it has a Close method: io.Closer's, as on
+// *sql.DB, or one without a result, as on *pgxpool.Pool. Other entities,
+// including nil, are left alone.
+//
+// Example:
+//
+//	if err := testdb.CloseEntity(db.Entity()); err != nil {
+//	    t.Logf("close entity: %v", err)
+//	}
+func CloseEntity(entity any) error {
+	switch e := entity.(type) {
+	case io.Closer:
+		return e.Close()
+	case interface{ Close() }:
+		e.Close()
+	}
+	return nil
+}
+
+// Reinitialize closes the current entity (see CloseEntity) and runs
+// the initializer again against the same database, replacing the entity.
+// The database itself and its data are left untouched.
+//
+// This supports connection-lifecycle tests, e.g. verifying that application
+// code recovers after its pool is closed. Fetch the new entity with Entity()
+// afterwards; the previous one must not be used.
+//
+// Returns ErrNoInitializer if the database was created without an initializer,
+// and ErrDatabaseClosed if Close has already been called.
+//
+// Example:
+//
+//	db := postgres.New(t, &postgres.PoolInitializer{})
+//	pool := db.Entity().(*pgxpool.Pool)
+//	// ...
+//	if err := db.Reinitialize(); err != nil {
+//	    t.Fatal(err)
+//	}
+//	pool = db.Entity().(*pgxpool.Pool) // fresh pool, same database
+func (td *TestDatabase) Reinitialize() error {
+	td.ensureCreated()
+
+	if td.initializer == nil {
+		return &Error{
+			Op:  "Reinitialize",
+			Err: ErrNoInitializer,
+		}
+	}
+
+	if td.closed() {
+		return &Error{
+			Op:  "Reinitialize",
+			Err: ErrDatabaseClosed,
+		}
+	}
+
+	if err := CloseEntity(td.entity); err != nil {
+		return &Error{
+			Op:  "Reinitialize",
+			Err: fmt.Errorf("close entity: %w", err),
+		}
+	}
+	td.entity = nil
+
+	entity, err := td.initializeEntity(context.Background())
+	if err != nil {
+		return err
+	}
+	td.entity = entity
+
+	td.logf("testdb: reinitialized entity for %s", td.name)
+	return nil
+}
+
+// RunAutoMigrate migrates the test database through its entity, which must
+// implement AutoMigrator; otherwise it returns ErrNotAutoMigrator.
+// postgres.New calls it when WithAutoMigrate is set.
+func (td *TestDatabase) RunAutoMigrate() error {
+	td.ensureCreated()
+
+	migrator, ok := td.entity.(AutoMigrator)
+	if !ok {
+		return &Error{
+			Op:  "RunAutoMigrate",
+			Err: fmt.Errorf("%w: %T", ErrNotAutoMigrator, td.entity),
+		}
+	}
+
+	if err := migrator.AutoMigrate(); err != nil {
+		return &Error{
+			Op:  "RunAutoMigrate",
+			Err: err,
+		}
+	}
+
+	td.logf("testdb: auto-migrated %s", td.name)
+	return nil
+}
+
+// logf logs a message if verbose mode is enabled.
+func (td *TestDatabase) logf(format string, args ...any) {
+	if td.config.Verbose {
+		td.t.Logf(format, args...)
+	}
 }
 
 // RunMigrations executes database migrations using the configured migration tool.
@@ -399,7 +1073,8 @@ func (td *TestDatabase) logf(format string, args ...any) {
 //   - golang-migrate (github.com/golang-migrate/migrate) - PostgreSQL, MySQL, SQLite, MongoDB, and more
 //
 // The migration tool binary must be available in PATH or specified via
-// WithMigrationToolPath() option.
+// WithMigrationToolPath() option. golang-migrate can alternatively run
+// in-process (no binary required) via the WithMigrateInProcess() option.
 //
 // Returns an error if migrations fail. The database is NOT automatically
 // cleaned up on migration failure - call Close() manually if needed.
@@ -419,26 +1094,505 @@ func (td *TestDatabase) logf(format string, args ...any) {
 //	    t.Fatalf("migrations failed: %v", err)
 //	}
 func (td *TestDatabase) RunMigrations() error {
+	td.ensureCreated()
+	_, err := td.runMigrations("RunMigrations")
+	return err
+}
+
+// RunMigrationsCaptured runs migrations like RunMigrations and also returns
+// the migration tool's output, e.g. to assert which versions were applied or
+// to debug a migration without enabling verbose logging. The output is
+// returned even when the migrations fail.
+//
+// For in-process golang-migrate (WithMigrateInProcess), the output is the
+// migrate library's log of the migrations it applied.
+//
+// Example:
+//
+//	output, err := db.RunMigrationsCaptured()
+//	if err != nil {
+//	    t.Fatalf("migrations failed: %v", err)
+//	}
+//	if !strings.Contains(output, "00002_add_email") {
+//	    t.Errorf("expected migration 2 to be applied, got:\n%s", output)
+//	}
+func (td *TestDatabase) RunMigrationsCaptured() (string, error) {
+	td.ensureCreated()
+	return td.runMigrations("RunMigrationsCaptured")
+}
+
+// runMigrations implements RunMigrations and RunMigrationsCaptured, returning
+// the migration tool's output. The op is used as the Op of any returned
+// *Error raised before the tool runs.
+func (td *TestDatabase) runMigrations(op string) (_ string, err error) {
+	defer func() { err = withDatabase(err, td.name) }()
+
 	if td.config.MigrationDir == "" {
+		return "", &Error{
+			Op:  op,
+			Err: ErrNoMigrationDir,
+		}
+	}
+
+	if err := td.checkMigrationToolVersion(op); err != nil {
+		return "", err
+	}
+
+	if err := td.checkMigrationFiles(op); err != nil {
+		return "", err
+	}
+
+	if td.config.PreMigrationHook != nil {
+		if err := td.config.PreMigrationHook(context.Background(), td.dsn); err != nil {
+			return "", &Error{
+				Op:  "PreMigrationHook",
+				Err: err,
+			}
+		}
+	}
+
+	start := time.Now()
+
+	var output string
+	switch td.config.MigrationTool {
+	case MigrationToolTern:
+		output, err = td.runTernMigrations()
+	case MigrationToolGoose:
+		output, err = td.runGooseMigrations()
+	case MigrationToolMigrate:
+		output, err = td.runMigrateMigrations()
+	default:
+		return "", &Error{
+			Op:  op,
+			Err: ErrUnknownMigrationTool,
+		}
+	}
+	if err != nil {
+		return output, err
+	}
+
+	duration := time.Since(start)
+
+	if td.config.VerifyMigrationsApplied {
+		if err := td.verifyMigrationsApplied(op); err != nil {
+			return output, err
+		}
+	}
+
+	td.migrationDuration = duration
+	td.migrated = true
+	td.logf("testdb: migrations completed for %s in %s", td.name, td.migrationDuration)
+
+	if td.config.PostMigrationHook != nil {
+		if err := td.config.PostMigrationHook(context.Background(), td.dsn); err != nil {
+			return output, &Error{
+				Op:  "PostMigrationHook",
+				Err: err,
+			}
+		}
+	}
+	return output, nil
+}
+
+// MigrationDuration returns how long the last successful RunMigrations call
+// took to run the migration tool, or 0 if migrations haven't run. This
+// includes migrations run automatically by helpers such as postgres.Setup.
+func (td *TestDatabase) MigrationDuration() time.Duration {
+	return td.migrationDuration
+}
+
+// RunMigrationsWithin runs migrations like RunMigrations and fails with
+// ErrMigrationsTooSlow if they take longer than limit. Use it in CI to catch
+// accidentally slow migrations, such as an index build scanning a large seed.
+//
+// Migrations are not interrupted when the limit is exceeded; they run to
+// completion and the error reports how long they took. To abort individual
+// slow statements, use WithMigrationStatementTimeout.
+//
+// Example:
+//
+//	if err := db.RunMigrationsWithin(5 * time.Second); err != nil {
+//	    t.Fatal(err) // e.g. "migrations took 7.2s, exceeding limit 5s"
+//	}
+func (td *TestDatabase) RunMigrationsWithin(limit time.Duration) error {
+	if limit <= 0 {
 		return &Error{
-			Op:  "RunMigrations",
+			Op:  "RunMigrationsWithin",
+			Err: fmt.Errorf("%w: %s", ErrInvalidMigrationTimeLimit, limit),
+		}
+	}
+
+	if err := td.RunMigrations(); err != nil {
+		return err
+	}
+
+	if td.migrationDuration > limit {
+		return &Error{
+			Op:  "RunMigrationsWithin",
+			Err: fmt.Errorf("%w: migrations took %s, exceeding limit %s", ErrMigrationsTooSlow, td.migrationDuration, limit),
+		}
+	}
+	return nil
+}
+
+// MigrateDatabase runs the migrations configured by opts (WithMigrations and
+// WithMigrationTool, plus any other migration options) against an existing
+// database, outside of a test. name identifies the database in verbose logs,
+// which are written with the standard log package.
+//
+// It is meant for setup code that runs before the tests, such as building a
+// template database in TestMain or a CI setup step. Inside a test, use
+// RunMigrations on the TestDatabase instead.
+//
+// Example:
+//
+//	err := testdb.MigrateDatabase("app_template", dsn,
+//	    testdb.WithMigrations("./migrations"),
+//	    testdb.WithMigrationTool(testdb.MigrationToolGoose))
+func MigrateDatabase(name, dsn string, opts ...Option) error {
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := validateConfig(cfg); err != nil {
+		return &Error{
+			Op:  "MigrateDatabase",
+			Err: err,
+		}
+	}
+
+	if len(cfg.MigrationFiles) > 0 {
+		dir, err := os.MkdirTemp("", "testdb-migrations-*")
+		if err != nil {
+			return &Error{
+				Op:  "MigrateDatabase",
+				Err: err,
+			}
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+
+		if err := writeMigrationFiles(dir, cfg.MigrationFiles); err != nil {
+			return &Error{
+				Op:  "writeMigrationFiles",
+				Err: err,
+			}
+		}
+		cfg.MigrationDir = dir
+	}
+
+	if len(cfg.MigrationDirsByDriver) > 0 {
+		dir, err := resolveMigrationDir(cfg, dsn)
+		if err != nil {
+			return &Error{
+				Op:  "resolveMigrationDir",
+				Err: err,
+			}
+		}
+		cfg.MigrationDir = dir
+	}
+
+	td := &TestDatabase{
+		name:   name,
+		dsn:    dsn,
+		config: cfg,
+		t:      stdLogger{},
+	}
+	return td.RunMigrations()
+}
+
+// stdLogger is a testingHelper that logs with the standard log package, for
+// TestDatabase values used outside of a test.
+type stdLogger struct{}
+
+func (stdLogger) Logf(format string, args ...any) { log.Printf(format, args...) }
+func (stdLogger) Helper()                         {}
+
+// RunMigrationsTo migrates the database to a specific version using the
+// configured migration tool. This is useful for testing a schema at an
+// intermediate version, or for testing that later migrations apply cleanly
+// on top of existing data.
+//
+// Tool mapping:
+//   - Tern: tern migrate -d <version> (migrates up or down to the version)
+//   - Goose: goose up-to <version> (only migrates up)
+//   - golang-migrate: migrate goto <version> (migrates up or down to the version)
+//
+// Example:
+//
+//	if err := db.RunMigrationsTo("1"); err != nil {
+//	    t.Fatalf("migrate to version 1 failed: %v", err)
+//	}
+func (td *TestDatabase) RunMigrationsTo(version string) error {
+	td.ensureCreated()
+
+	if td.config.MigrationDir == "" {
+		return &Error{
+			Op:  "RunMigrationsTo",
+			Err: ErrNoMigrationDir,
+		}
+	}
+
+	if err := td.checkMigrationToolVersion("RunMigrationsTo"); err != nil {
+		return err
+	}
+
+	if version == "" {
+		return &Error{
+			Op:  "RunMigrationsTo",
+			Err: ErrInvalidMigrationVersion,
+		}
+	}
+
+	var err error
+	switch td.config.MigrationTool {
+	case MigrationToolTern:
+		_, err = td.runTern("RunMigrationsTo", "-d", version)
+	case MigrationToolGoose:
+		_, err = td.runGoose("RunMigrationsTo", "up-to", version)
+	case MigrationToolMigrate:
+		v, parseErr := strconv.ParseUint(version, 10, 64)
+		if parseErr != nil {
+			return &Error{
+				Op:  "RunMigrationsTo",
+				Err: fmt.Errorf("%w: %q", ErrInvalidMigrationVersion, version),
+			}
+		}
+		_, err = td.runMigrate("RunMigrationsTo", migrateCommand{
+			args: []string{"goto", version},
+			run:  func(m *migrate.Migrate) error { return m.Migrate(uint(v)) },
+		})
+	default:
+		return &Error{
+			Op:  "RunMigrationsTo",
+			Err: ErrUnknownMigrationTool,
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	td.logf("testdb: migrated %s to version %s", td.name, version)
+	return nil
+}
+
+// RunMigrationsUpByOne applies exactly one pending migration using the
+// configured migration tool. Calling it repeatedly steps through the
+// migrations one at a time, so a test can check the database after each step,
+// e.g. that a data migration transformed existing rows correctly.
+//
+// Tool mapping:
+//   - Goose: goose up-by-one
+//   - golang-migrate: migrate up 1
+//   - Tern: not supported - returns ErrUnsupportedMigrationOperation.
+//     Use RunMigrationsTo with an explicit version instead.
+//
+// When no migration is pending, the tool's error is returned (goose reports
+// "no next version found"; golang-migrate reports the file does not exist).
+//
+// Example:
+//
+//	if err := db.RunMigrationsUpByOne(); err != nil { // 001: create table
+//	    t.Fatal(err)
+//	}
+//	seedLegacyRows(t, pool)
+//	if err := db.RunMigrationsUpByOne(); err != nil { // 002: backfill column
+//	    t.Fatal(err)
+//	}
+//	assertBackfilled(t, pool)
+func (td *TestDatabase) RunMigrationsUpByOne() error {
+	td.ensureCreated()
+
+	if td.config.MigrationDir == "" {
+		return &Error{
+			Op:  "RunMigrationsUpByOne",
+			Err: ErrNoMigrationDir,
+		}
+	}
+
+	if err := td.checkMigrationToolVersion("RunMigrationsUpByOne"); err != nil {
+		return err
+	}
+
+	var err error
+	switch td.config.MigrationTool {
+	case MigrationToolTern:
+		return &Error{
+			Op:  "RunMigrationsUpByOne",
+			Err: fmt.Errorf("%w: tern does not support stepping one migration, use RunMigrationsTo", ErrUnsupportedMigrationOperation),
+		}
+	case MigrationToolGoose:
+		_, err = td.runGoose("RunMigrationsUpByOne", "up-by-one")
+	case MigrationToolMigrate:
+		_, err = td.runMigrate("RunMigrationsUpByOne", migrateCommand{
+			args: []string{"up", "1"},
+			run:  func(m *migrate.Migrate) error { return m.Steps(1) },
+		})
+	default:
+		return &Error{
+			Op:  "RunMigrationsUpByOne",
+			Err: ErrUnknownMigrationTool,
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	td.logf("testdb: applied one migration for %s", td.name)
+	return nil
+}
+
+// RollbackMigrations rolls back the given number of most recently applied
+// migrations using the configured migration tool. This is useful for testing
+// that down migrations are reversible.
+//
+// Tool mapping:
+//   - Goose: goose down (once per step)
+//   - golang-migrate: migrate down <steps>
+//   - Tern: not supported - returns ErrUnsupportedMigrationOperation.
+//     Use RunMigrationsTo with an explicit version instead.
+//
+// Example:
+//
+//	if err := db.RollbackMigrations(1); err != nil {
+//	    t.Fatalf("rollback failed: %v", err)
+//	}
+func (td *TestDatabase) RollbackMigrations(steps int) error {
+	td.ensureCreated()
+
+	if td.config.MigrationDir == "" {
+		return &Error{
+			Op:  "RollbackMigrations",
 			Err: ErrNoMigrationDir,
 		}
 	}
 
+	if err := td.checkMigrationToolVersion("RollbackMigrations"); err != nil {
+		return err
+	}
+
+	if steps < 1 {
+		return &Error{
+			Op:  "RollbackMigrations",
+			Err: fmt.Errorf("%w: got %d", ErrInvalidMigrationSteps, steps),
+		}
+	}
+
 	switch td.config.MigrationTool {
 	case MigrationToolTern:
-		return td.runTernMigrations()
+		return &Error{
+			Op:  "RollbackMigrations",
+			Err: fmt.Errorf("%w: tern does not support step-based rollback, use RunMigrationsTo", ErrUnsupportedMigrationOperation),
+		}
 	case MigrationToolGoose:
-		return td.runGooseMigrations()
+		for range steps {
+			if _, err := td.runGoose("RollbackMigrations", "down"); err != nil {
+				return err
+			}
+		}
 	case MigrationToolMigrate:
-		return td.runMigrateMigrations()
+		_, err := td.runMigrate("RollbackMigrations", migrateCommand{
+			args: []string{"down", strconv.Itoa(steps)},
+			run:  func(m *migrate.Migrate) error { return m.Steps(-steps) },
+		})
+		if err != nil {
+			return err
+		}
 	default:
 		return &Error{
-			Op:  "RunMigrations",
+			Op:  "RollbackMigrations",
 			Err: ErrUnknownMigrationTool,
 		}
 	}
+
+	td.logf("testdb: rolled back %d migration(s) for %s", steps, td.name)
+	return nil
+}
+
+// MigrationStatus reports the migration version the test database is at, as
+// recorded in the migration tool's version table, so tests can assert that
+// migrations advanced to the expected version. current is "" when no
+// migrations have been applied.
+//
+// dirty reports golang-migrate's dirty flag, set when a migration failed
+// partway and the database needs manual repair; it is always false for tern
+// and goose.
+//
+// Tool mapping:
+//   - Tern: the version in schema_version
+//   - Goose: the highest applied version_id in goose_db_version
+//   - golang-migrate: version and dirty in schema_migrations
+//
+// A table set with WithMigrationTable is read instead of the default. Only
+// PostgreSQL databases are supported; others return
+// ErrUnsupportedMigrationOperation.
+//
+// Example:
+//
+//	if err := db.RunMigrationsTo("2"); err != nil {
+//	    t.Fatal(err)
+//	}
+//	current, dirty, err := db.MigrationStatus()
+//	if err != nil || dirty || current != "2" {
+//	    t.Fatalf("expected clean version 2, got %q (dirty %t, err %v)", current, dirty, err)
+//	}
+func (td *TestDatabase) MigrationStatus() (current string, dirty bool, err error) {
+	td.ensureCreated()
+
+	if td.config.MigrationTool == "" {
+		return "", false, &Error{
+			Op:  "MigrationStatus",
+			Err: ErrUnknownMigrationTool,
+		}
+	}
+	if driver, err := driverFromDSN(td.dsn); err != nil || driver != "postgres" {
+		return "", false, &Error{
+			Op:  "MigrationStatus",
+			Err: fmt.Errorf("%w: reading migration status requires a PostgreSQL database", ErrUnsupportedMigrationOperation),
+		}
+	}
+
+	current, dirty, err = td.readMigrationStatus(context.Background())
+	if err != nil {
+		return "", false, &Error{
+			Op:  "MigrationStatus",
+			Err: err,
+		}
+	}
+	return current, dirty, nil
+}
+
+// rollbackAllMigrations rolls back every applied migration, for
+// WithVerifyDownMigrations.
+//
+// Tool mapping:
+//   - Tern: tern migrate -d 0
+//   - Goose: goose reset
+//   - golang-migrate: migrate down -all
+func (td *TestDatabase) rollbackAllMigrations(op string) error {
+	var err error
+	switch td.config.MigrationTool {
+	case MigrationToolTern:
+		_, err = td.runTern(op, "-d", "0")
+	case MigrationToolGoose:
+		_, err = td.runGoose(op, "reset")
+	case MigrationToolMigrate:
+		_, err = td.runMigrate(op, migrateCommand{
+			args: []string{"down", "-all"},
+			run:  func(m *migrate.Migrate) error { return m.Down() },
+		})
+	default:
+		return &Error{
+			Op:  op,
+			Err: ErrUnknownMigrationTool,
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	td.logf("testdb: rolled back all migrations for %s", td.name)
+	return nil
 }
 
 // Close cleans up the test database and associated resources.
@@ -473,11 +1627,36 @@ func (td *TestDatabase) RunMigrations() error {
 //
 //	pool := postgres.Setup(t)  // Cleanup registered automatically
 //	// No need to call Close() - handled by t.Cleanup()
+//
+// Close is idempotent and safe for concurrent use: the database is dropped
+// once, and every later call returns nil.
 func (td *TestDatabase) Close() error {
 	td.t.Helper()
 
+	if td.create != nil {
+		// A WithLazyCreate database that was never used is never created.
+		td.createOnce.Do(func() { td.createErr = ErrDatabaseClosed })
+	}
+
+	return td.drop()
+}
+
+// closed reports whether Close has been called (or, for a WithLazyCreate
+// database, whether creation hasn't happened).
+func (td *TestDatabase) closed() bool {
+	td.closeMu.Lock()
+	defer td.closeMu.Unlock()
+	return td.cleanup == nil
+}
+
+// drop implements Close, dropping the database if it exists. Callers
+// arriving while another drop is in progress wait for it and return nil.
+func (td *TestDatabase) drop() error {
+	td.closeMu.Lock()
+	defer td.closeMu.Unlock()
+
 	if td.cleanup == nil {
-		return nil // Already closed
+		return nil // Already closed, or never created
 	}
 
 	td.logf("testdb: cleaning up database %s", td.name)